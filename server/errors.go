@@ -0,0 +1,80 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tygermarshall/blunderbuss/shared"
+	"github.com/tygermarshall/blunderbuss/shared/board"
+)
+
+// Codes reported in shared.ErrorResponse.Code.
+const (
+	codeInvalidRequest = "invalid_request"
+	codeInternal       = "internal_error"
+	codeIllegalMove    = "illegal_move"
+	codeInvalidFEN     = "invalid_fen"
+	codeInvalidPGN     = "invalid_pgn"
+)
+
+// errorCodes maps known errors to the HTTP status and code they are
+// reported with. The first entry err matches with errors.Is wins.
+var errorCodes = []struct {
+	err    error
+	status int
+	code   string
+}{
+	{ErrGameNotFound, http.StatusNotFound, "game_not_found"},
+	{ErrNoDrawClaim, http.StatusConflict, "no_draw_claim"},
+	{ErrResultConflict, http.StatusBadRequest, codeInvalidPGN},
+	{ErrInvalidPGN, http.StatusBadRequest, codeInvalidPGN},
+	{ErrInvalidRequest, http.StatusBadRequest, codeInvalidRequest},
+	{board.ErrInvalidFEN, http.StatusBadRequest, codeInvalidFEN},
+	{board.ErrInvalidUCI, http.StatusBadRequest, "invalid_uci"},
+	{board.ErrGameOver, http.StatusConflict, "game_over"},
+	{board.ErrWrongTurn, http.StatusConflict, "wrong_turn"},
+	{board.ErrOutOfBounds, http.StatusBadRequest, "out_of_bounds"},
+	{board.ErrEmptySquare, http.StatusBadRequest, "empty_square"},
+	{board.ErrInvalidMovement, http.StatusBadRequest, "invalid_movement"},
+	{board.ErrPathBlocked, http.StatusBadRequest, "path_blocked"},
+	{board.ErrOwnPieceCapture, http.StatusBadRequest, "own_piece_capture"},
+	{board.ErrInvalidPromotion, http.StatusBadRequest, "invalid_promotion"},
+	{board.ErrCastlingForbidden, http.StatusBadRequest, "castling_forbidden"},
+	{board.ErrKingInCheck, http.StatusBadRequest, "king_in_check"},
+}
+
+// ErrInvalidRequest is wrapped by errors for request bodies that are not
+// well formed.
+var ErrInvalidRequest = errors.New("invalid request")
+
+// ErrInvalidPGN is wrapped by errors for PGN text that cannot be imported.
+// It comes before the board errors in errorCodes, so that an illegal move
+// in the PGN is reported as invalid_pgn.
+var ErrInvalidPGN = errors.New("invalid pgn")
+
+// writeError responds with status and a shared.ErrorResponse.
+func writeError(c *gin.Context, status int, code string, message string) {
+	c.JSON(status, shared.ErrorResponse{Code: code, Message: message})
+}
+
+// writeErr responds with the status and body errorResponse gives err.
+func writeErr(c *gin.Context, err error) {
+	status, body := errorResponse(err)
+	c.JSON(status, body)
+}
+
+// errorResponse returns the status and code errorCodes gives err. Unknown
+// illegal moves are bad requests and anything else is an internal error.
+func errorResponse(err error) (int, shared.ErrorResponse) {
+	for _, e := range errorCodes {
+		if errors.Is(err, e.err) {
+			return e.status, shared.ErrorResponse{Code: e.code, Message: err.Error()}
+		}
+	}
+	var illegal *board.IllegalMoveError
+	if errors.As(err, &illegal) {
+		return http.StatusBadRequest, shared.ErrorResponse{Code: codeIllegalMove, Message: err.Error()}
+	}
+	return http.StatusInternalServerError, shared.ErrorResponse{Code: codeInternal, Message: err.Error()}
+}
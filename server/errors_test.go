@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tygermarshall/blunderbuss/shared"
+	"github.com/tygermarshall/blunderbuss/shared/board"
+)
+
+func TestErrorResponse(t *testing.T) {
+	_, fenErr := board.ParseFEN("8/8/8/8/8/8/8/8 w - - 0 1")
+	_, _, moveErr := board.CreateDefaultBoard().MovePiece(board.NewMove(board.E1, board.E2))
+
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+	}{
+		{"game not found", ErrGameNotFound, http.StatusNotFound, "game_not_found"},
+		{"no draw claim", ErrNoDrawClaim, http.StatusConflict, "no_draw_claim"},
+		{"result conflict", ErrResultConflict, http.StatusBadRequest, "invalid_pgn"},
+		{"invalid pgn", ErrInvalidPGN, http.StatusBadRequest, "invalid_pgn"},
+		{"invalid request", ErrInvalidRequest, http.StatusBadRequest, "invalid_request"},
+		{"invalid fen", board.ErrInvalidFEN, http.StatusBadRequest, "invalid_fen"},
+		{"invalid uci", board.ErrInvalidUCI, http.StatusBadRequest, "invalid_uci"},
+		{"game over", board.ErrGameOver, http.StatusConflict, "game_over"},
+		{"wrong turn", board.ErrWrongTurn, http.StatusConflict, "wrong_turn"},
+		{"out of bounds", board.ErrOutOfBounds, http.StatusBadRequest, "out_of_bounds"},
+		{"empty square", board.ErrEmptySquare, http.StatusBadRequest, "empty_square"},
+		{"invalid movement", board.ErrInvalidMovement, http.StatusBadRequest, "invalid_movement"},
+		{"path blocked", board.ErrPathBlocked, http.StatusBadRequest, "path_blocked"},
+		{"own piece capture", board.ErrOwnPieceCapture, http.StatusBadRequest, "own_piece_capture"},
+		{"invalid promotion", board.ErrInvalidPromotion, http.StatusBadRequest, "invalid_promotion"},
+		{"castling forbidden", board.ErrCastlingForbidden, http.StatusBadRequest, "castling_forbidden"},
+		{"king in check", board.ErrKingInCheck, http.StatusBadRequest, "king_in_check"},
+
+		{"ParseFEN error", fenErr, http.StatusBadRequest, "invalid_fen"},
+		{"MovePiece error", moveErr, http.StatusBadRequest, "own_piece_capture"},
+		{"illegal move without a known reason", &board.IllegalMoveError{Reason: errors.New("no")}, http.StatusBadRequest, "illegal_move"},
+		// a PGN error is reported as such, whatever it wraps
+		{"illegal move in PGN", fmt.Errorf("%w: %w", ErrInvalidPGN, moveErr), http.StatusBadRequest, "invalid_pgn"},
+		{"FEN tag in PGN", fmt.Errorf("%w: %w", ErrInvalidPGN, fenErr), http.StatusBadRequest, "invalid_pgn"},
+		{"unknown", errors.New("disk on fire"), http.StatusInternalServerError, "internal_error"},
+	}
+	for _, tt := range tests {
+		if tt.err == nil {
+			t.Fatalf("%s: no error to report", tt.name)
+		}
+		status, body := errorResponse(fmt.Errorf("wrapped: %w", tt.err))
+		if status != tt.status || body.Code != tt.code {
+			t.Errorf("%s: reported as %d %s, want %d %s", tt.name, status, body.Code, tt.status, tt.code)
+		}
+	}
+
+	// every entry in the table is reachable
+	for _, e := range errorCodes {
+		if status, body := errorResponse(e.err); status != e.status || body.Code != e.code {
+			t.Errorf("%v: reported as %d %s, want %d %s", e.err, status, body.Code, e.status, e.code)
+		}
+	}
+}
+
+func TestNewGameErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/games", startNewGame)
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		code   string
+	}{
+		{"start position", ``, http.StatusCreated, ""},
+		{"FEN", `{"fen": "4k3/8/8/8/8/8/8/4K3 w - - 0 1"}`, http.StatusCreated, ""},
+		{"PGN", `{"pgn": "1. e4 e5 *"}`, http.StatusCreated, ""},
+		{"not JSON", `{`, http.StatusBadRequest, "invalid_request"},
+		{"FEN and PGN", `{"fen": "4k3/8/8/8/8/8/8/4K3 w - - 0 1", "pgn": "1. e4 *"}`, http.StatusBadRequest, "invalid_request"},
+		{"bad FEN", `{"fen": "4k3/8/8/8/8/8/8/4K3 w - -"}`, http.StatusBadRequest, "invalid_fen"},
+		{"PGN syntax", `{"pgn": "1. e4 $x *"}`, http.StatusBadRequest, "invalid_pgn"},
+		{"illegal move in PGN", `{"pgn": "1. e4 e5 2. Ke3 *"}`, http.StatusBadRequest, "invalid_pgn"},
+		{"bad FEN tag in PGN", `{"pgn": "[FEN \"8/8/8/8/8/8/8/8 w - - 0 1\"]\n\n*"}`, http.StatusBadRequest, "invalid_pgn"},
+		{"no game in PGN", `{"pgn": "   "}`, http.StatusBadRequest, "invalid_pgn"},
+		{"PGN result conflict", `{"pgn": "1. f3 e5 2. g4 Qh4# 1-0"}`, http.StatusBadRequest, "invalid_pgn"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/games", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tt.status {
+				t.Fatalf("status %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			if tt.code == "" {
+				return
+			}
+			var body shared.ErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Code != tt.code {
+				t.Errorf("code %q, want %q: %s", body.Code, tt.code, body.Message)
+			}
+		})
+	}
+}
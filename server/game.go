@@ -4,17 +4,138 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"errors"
+	"fmt"
 	"sync"
+	"time"
 
 	"github.com/tygermarshall/blunderbuss/shared"
 	"github.com/tygermarshall/blunderbuss/shared/board"
+	"github.com/tygermarshall/blunderbuss/shared/pieces"
 )
 
 var ErrGameNotFound = errors.New("game not found")
 
+// ErrNoDrawClaim is returned when a draw is claimed but neither the
+// threefold repetition nor the fifty-move rule allows it.
+var ErrNoDrawClaim = errors.New("no draw can be claimed")
+
+// ErrResultConflict is returned when an imported game's recorded result
+// is not the result its moves reach.
+var ErrResultConflict = errors.New("recorded result does not match the final position")
+
+// GameResult is the outcome of a game, or ResultOngoing while it is still
+// being played.
+type GameResult string
+
+const (
+	ResultOngoing   GameResult = "ongoing"
+	ResultWhiteWins GameResult = "white_wins"
+	ResultBlackWins GameResult = "black_wins"
+	ResultDraw      GameResult = "draw"
+)
+
+// EndReason explains how a finished game ended.
+type EndReason string
+
+const (
+	ReasonNone      EndReason = ""
+	ReasonCheckmate EndReason = "checkmate"
+	ReasonStalemate EndReason = "stalemate"
+	// ReasonInsufficientMaterial, ReasonFivefoldRepetition and
+	// ReasonSeventyFiveMoves are draws declared automatically.
+	ReasonInsufficientMaterial EndReason = "insufficient_material"
+	ReasonFivefoldRepetition   EndReason = "fivefold_repetition"
+	ReasonSeventyFiveMoves     EndReason = "seventy_five_move_rule"
+	// ReasonThreefoldRepetition and ReasonFiftyMoves are draws a player
+	// claimed.
+	ReasonThreefoldRepetition EndReason = "threefold_repetition"
+	ReasonFiftyMoves          EndReason = "fifty_move_rule"
+	// ReasonRecorded is the result recorded in an imported game that its
+	// moves do not reach, such as a resignation, a loss on time or a draw
+	// by agreement.
+	ReasonRecorded EndReason = "recorded"
+)
+
 type Game struct {
-	Board      board.Board
-	TurnNumber int
+	// Start is the position the game began from and Board the current one.
+	Start board.Board
+	Board board.Board
+	// Moves lists every move played so far, in order.
+	Moves   []board.Move
+	Result  GameResult
+	Reason  EndReason
+	Created time.Time
+	// positions holds the board.PositionKey of the start position and of
+	// the position after each move.
+	positions []uint64
+}
+
+// Finished reports whether the game has a result.
+func (g *Game) Finished() bool {
+	return g.Result != ResultOngoing
+}
+
+// TurnNumber is the board's fullmove number.
+func (g *Game) TurnNumber() int {
+	return g.Board.FullmoveNumber
+}
+
+// Repetitions counts how many times the current position has occurred,
+// including now.
+func (g *Game) Repetitions() int {
+	n := 0
+	current := g.positions[len(g.positions)-1]
+	for _, p := range g.positions {
+		if p == current {
+			n++
+		}
+	}
+	return n
+}
+
+// drawClaim returns the reason the side to move may claim a draw, or
+// ReasonNone if it may not.
+func (g *Game) drawClaim() EndReason {
+	switch {
+	case g.Finished():
+		return ReasonNone
+	case g.Repetitions() >= 3:
+		return ReasonThreefoldRepetition
+	case g.Board.CanClaimFiftyMoveDraw():
+		return ReasonFiftyMoves
+	}
+	return ReasonNone
+}
+
+// CanClaimDraw reports whether the side to move may claim a draw.
+func (g *Game) CanClaimDraw() bool {
+	return g.drawClaim() != ReasonNone
+}
+
+// updateResult ends the game if the side to move has been checkmated or
+// stalemated, or if the game is drawn by a rule that needs no claim.
+// Checkmate takes precedence over the seventy-five-move rule.
+func (g *Game) updateResult() {
+	switch {
+	case g.Board.IsCheckmate():
+		g.Result = ResultWhiteWins
+		if g.Board.SideToMove == pieces.White {
+			g.Result = ResultBlackWins
+		}
+		g.Reason = ReasonCheckmate
+	case g.Board.IsStalemate():
+		g.Result = ResultDraw
+		g.Reason = ReasonStalemate
+	case g.Board.IsInsufficientMaterial():
+		g.Result = ResultDraw
+		g.Reason = ReasonInsufficientMaterial
+	case g.Repetitions() >= 5:
+		g.Result = ResultDraw
+		g.Reason = ReasonFivefoldRepetition
+	case g.Board.IsSeventyFiveMoveDraw():
+		g.Result = ResultDraw
+		g.Reason = ReasonSeventyFiveMoves
+	}
 }
 
 // gameEntry holds a game and its own mutex so one game's operations
@@ -35,11 +156,39 @@ func NewGameStore() *GameStore {
 	return &GameStore{games: make(map[string]*gameEntry)}
 }
 
-func newGame() *Game {
-	return &Game{
-		Board:      board.CreateDefaultBoard(),
-		TurnNumber: 1,
+// newGame starts a game from start and plays moves on it.
+func newGame(start board.Board, moves []board.Move) (*Game, error) {
+	g := &Game{
+		Start:     start,
+		Board:     start,
+		Result:    ResultOngoing,
+		Created:   time.Now(),
+		positions: []uint64{start.PositionKey()},
+	}
+	g.updateResult()
+	for _, m := range moves {
+		if _, err := g.play(m); err != nil {
+			return nil, err
+		}
 	}
+	return g, nil
+}
+
+// play applies m to the game, records it and checks whether the game is
+// over. It returns the move as applied by the board.
+func (g *Game) play(m board.Move) (board.Move, error) {
+	if g.Finished() {
+		return m, &board.IllegalMoveError{Move: m, Reason: board.ErrGameOver}
+	}
+	newBoard, applied, err := g.Board.MovePiece(m)
+	if err != nil {
+		return m, err
+	}
+	g.Board = newBoard
+	g.Moves = append(g.Moves, applied)
+	g.positions = append(g.positions, newBoard.PositionKey())
+	g.updateResult()
+	return applied, nil
 }
 
 func generateID() (string, error) {
@@ -50,13 +199,36 @@ func generateID() (string, error) {
 	return hex.EncodeToString(b), nil
 }
 
-// Create creates a new game, stores it, and returns its ID.
-func (s *GameStore) Create() (string, error) {
+// Import creates a game starting from start, plays moves on it, stores it
+// and returns its ID. result is the result recorded for the game, or
+// ResultOngoing for a new game. If the moves end the game, a finished
+// result must be the one they reach, and ErrResultConflict is returned
+// otherwise. If they do not, a finished result is kept with
+// ReasonRecorded. An illegal move returns its board error. Nothing is
+// stored on error.
+func (s *GameStore) Import(start board.Board, moves []board.Move, result GameResult) (string, error) {
+	g, err := newGame(start, moves)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case g.Finished():
+		if result != ResultOngoing && result != g.Result {
+			return "", fmt.Errorf("%w: recorded %s, position is %s by %s", ErrResultConflict, result, g.Result, g.Reason)
+		}
+	case result != ResultOngoing:
+		g.Result = result
+		g.Reason = ReasonRecorded
+	}
+	return s.add(g)
+}
+
+// add stores g under a new ID and returns the ID.
+func (s *GameStore) add(g *Game) (string, error) {
 	id, err := generateID()
 	if err != nil {
 		return "", err
 	}
-	g := newGame()
 	s.mu.Lock()
 	s.games[id] = &gameEntry{game: g}
 	shared.PrintBoard(&g.Board)
@@ -73,26 +245,64 @@ func (s *GameStore) Get(id string) (*Game, bool) {
 		return nil, false
 	}
 	entry.mu.Lock()
-	cp := &Game{Board: entry.game.Board, TurnNumber: entry.game.TurnNumber}
-	entry.mu.Unlock()
-	return cp, true
+	defer entry.mu.Unlock()
+	return entry.game.clone(), true
+}
+
+// clone returns a copy of g that shares nothing with it.
+func (g *Game) clone() *Game {
+	cp := *g
+	cp.Moves = append([]board.Move(nil), g.Moves...)
+	cp.positions = append([]uint64(nil), g.positions...)
+	return &cp
 }
 
-// Move applies a move to the game. Returns ErrGameNotFound or the board move error.
-func (s *GameStore) Move(id string, from, to board.Coordinate) error {
+// Move applies a move to the game and returns it as applied by the board,
+// with a copy of the game straight after it, taken before any other move
+// can be played.
+// Castling is requested as a two-square king move; the board moves the rook
+// as part of the same update.
+// Returns ErrGameNotFound or a *board.IllegalMoveError, whose reason is
+// board.ErrGameOver once the game has a result.
+func (s *GameStore) Move(id string, m board.Move) (board.Move, *Game, error) {
 	s.mu.RLock()
 	entry := s.games[id]
 	s.mu.RUnlock()
 	if entry == nil {
-		return ErrGameNotFound
+		return m, nil, ErrGameNotFound
 	}
 	entry.mu.Lock()
 	defer entry.mu.Unlock()
-	newBoard, err := entry.game.Board.MovePiece(from, to)
+	applied, err := entry.game.play(m)
 	if err != nil {
-		return err
+		return applied, nil, err
+	}
+	return applied, entry.game.clone(), nil
+}
+
+// ClaimDraw ends the game as a draw on behalf of the side to move, if the
+// current position has occurred three times or fifty moves have passed
+// without a capture or pawn move. It returns the reason the claim was
+// granted, ErrGameNotFound, ErrNoDrawClaim, or an *board.IllegalMoveError
+// with reason board.ErrGameOver once the game has a result.
+func (s *GameStore) ClaimDraw(id string) (EndReason, error) {
+	s.mu.RLock()
+	entry := s.games[id]
+	s.mu.RUnlock()
+	if entry == nil {
+		return ReasonNone, ErrGameNotFound
+	}
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+	g := entry.game
+	if g.Finished() {
+		return ReasonNone, &board.IllegalMoveError{Reason: board.ErrGameOver}
+	}
+	reason := g.drawClaim()
+	if reason == ReasonNone {
+		return ReasonNone, ErrNoDrawClaim
 	}
-	entry.game.Board = newBoard
-	entry.game.TurnNumber++
-	return nil
+	g.Result = ResultDraw
+	g.Reason = reason
+	return reason, nil
 }
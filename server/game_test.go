@@ -0,0 +1,159 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/tygermarshall/blunderbuss/shared/board"
+)
+
+// importFEN stores a new game from fen, failing t on error.
+func importFEN(t *testing.T, s *GameStore, fen string) string {
+	t.Helper()
+	b, err := board.ParseFEN(fen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := s.Import(b, nil, ResultOngoing)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+// play plays UCI moves in game id, failing t on the first error.
+func play(t *testing.T, s *GameStore, id string, moves ...string) *Game {
+	t.Helper()
+	var g *Game
+	for _, uci := range moves {
+		m, err := board.ParseUCI(uci)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, g, err = s.Move(id, m); err != nil {
+			t.Fatalf("%s: %v", uci, err)
+		}
+	}
+	return g
+}
+
+// knightShuffle returns to the starting position after four plies.
+var knightShuffle = []string{"g1f3", "g8f6", "f3g1", "f6g8"}
+
+func TestThreefoldRepetitionClaim(t *testing.T) {
+	s := NewGameStore()
+	id := importFEN(t, s, board.StartFEN)
+
+	play(t, s, id, knightShuffle...)
+	if _, err := s.ClaimDraw(id); !errors.Is(err, ErrNoDrawClaim) {
+		t.Fatalf("claim after two occurrences: got %v, want ErrNoDrawClaim", err)
+	}
+
+	g := play(t, s, id, knightShuffle...)
+	if g.Repetitions() != 3 || !g.CanClaimDraw() || g.Finished() {
+		t.Fatalf("after three occurrences: repetitions %d, can claim %v, finished %v", g.Repetitions(), g.CanClaimDraw(), g.Finished())
+	}
+	reason, err := s.ClaimDraw(id)
+	if err != nil || reason != ReasonThreefoldRepetition {
+		t.Fatalf("claim: got %q, %v, want %q", reason, err, ReasonThreefoldRepetition)
+	}
+	g, _ = s.Get(id)
+	if g.Result != ResultDraw || g.Reason != ReasonThreefoldRepetition {
+		t.Errorf("got %s by %s, want a draw by threefold repetition", g.Result, g.Reason)
+	}
+	if _, err := s.ClaimDraw(id); !errors.Is(err, board.ErrGameOver) {
+		t.Errorf("second claim: got %v, want ErrGameOver", err)
+	}
+}
+
+func TestFivefoldRepetitionEndsGame(t *testing.T) {
+	s := NewGameStore()
+	id := importFEN(t, s, board.StartFEN)
+	var g *Game
+	for i := 0; i < 4; i++ {
+		g = play(t, s, id, knightShuffle...)
+	}
+	if g.Result != ResultDraw || g.Reason != ReasonFivefoldRepetition {
+		t.Errorf("got %s by %q, want a draw by fivefold repetition", g.Result, g.Reason)
+	}
+}
+
+func TestFiftyMoveClaim(t *testing.T) {
+	s := NewGameStore()
+	id := importFEN(t, s, "4k3/8/8/8/8/8/8/R3K3 w - - 98 80")
+	play(t, s, id, "a1a2")
+	if _, err := s.ClaimDraw(id); !errors.Is(err, ErrNoDrawClaim) {
+		t.Fatalf("claim at clock 99: got %v, want ErrNoDrawClaim", err)
+	}
+	g := play(t, s, id, "e8d8")
+	if g.Board.HalfmoveClock != 100 || g.Finished() {
+		t.Fatalf("clock %d, finished %v, want 100 and ongoing", g.Board.HalfmoveClock, g.Finished())
+	}
+	if reason, err := s.ClaimDraw(id); err != nil || reason != ReasonFiftyMoves {
+		t.Fatalf("claim at clock 100: got %q, %v, want %q", reason, err, ReasonFiftyMoves)
+	}
+}
+
+func TestSeventyFiveMoveRuleEndsGame(t *testing.T) {
+	s := NewGameStore()
+	id := importFEN(t, s, "4k3/8/8/8/8/8/8/R3K3 w - - 148 80")
+	g := play(t, s, id, "a1a2")
+	if g.Finished() {
+		t.Fatalf("finished at clock 149 by %s", g.Reason)
+	}
+	g = play(t, s, id, "e8d8")
+	if g.Result != ResultDraw || g.Reason != ReasonSeventyFiveMoves {
+		t.Errorf("at clock 150: got %s by %q, want a draw by the seventy-five-move rule", g.Result, g.Reason)
+	}
+}
+
+func TestInsufficientMaterial(t *testing.T) {
+	tests := []struct {
+		name string
+		fen  string
+		draw bool
+	}{
+		{"KvK", "4k3/8/8/8/8/8/8/4K3 w - - 0 1", true},
+		{"KBvK", "4k3/8/8/8/8/8/8/2B1K3 w - - 0 1", true},
+		{"KNvK", "4k3/8/8/8/8/8/8/1N2K3 w - - 0 1", true},
+		{"same-colour bishops", "3bk3/8/8/8/8/8/8/2B1K3 w - - 0 1", true},
+		{"opposite-colour bishops", "2b1k3/8/8/8/8/8/8/2B1K3 w - - 0 1", false},
+		{"KNNvK", "4k3/8/8/8/8/8/8/1N2KN2 w - - 0 1", false},
+		{"KPvK", "4k3/8/8/8/8/8/4P3/4K3 w - - 0 1", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewGameStore()
+			g, _ := s.Get(importFEN(t, s, tt.fen))
+			drawn := g.Result == ResultDraw && g.Reason == ReasonInsufficientMaterial
+			if drawn != tt.draw {
+				t.Errorf("got %s by %q, want drawn %v", g.Result, g.Reason, tt.draw)
+			}
+		})
+	}
+
+	// a capture that leaves bare kings ends the game at once
+	s := NewGameStore()
+	id := importFEN(t, s, "4k3/8/8/8/8/8/3r4/4K3 w - - 0 1")
+	if g := play(t, s, id, "e1d2"); g.Reason != ReasonInsufficientMaterial {
+		t.Errorf("after Kxd2: got %s by %q, want a draw by insufficient material", g.Result, g.Reason)
+	}
+}
+
+func TestMoveIntoFinishedGame(t *testing.T) {
+	s := NewGameStore()
+	id := importFEN(t, s, board.StartFEN)
+	g := play(t, s, id, "f2f3", "e7e5", "g2g4", "d8h4")
+	if g.Result != ResultBlackWins || g.Reason != ReasonCheckmate {
+		t.Fatalf("got %s by %q, want black to win by checkmate", g.Result, g.Reason)
+	}
+	m, _ := board.ParseUCI("e1f2")
+	_, _, err := s.Move(id, m)
+	if !errors.Is(err, board.ErrGameOver) {
+		t.Fatalf("got %v, want ErrGameOver", err)
+	}
+	if status, body := errorResponse(err); status != http.StatusConflict || body.Code != "game_over" {
+		t.Errorf("reported as %d %s, want 409 game_over", status, body.Code)
+	}
+}
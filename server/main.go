@@ -3,13 +3,19 @@ package main
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"log"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
 	"github.com/tygermarshall/blunderbuss/shared"
 	"github.com/tygermarshall/blunderbuss/shared/board"
+	"github.com/tygermarshall/blunderbuss/shared/pgn"
+	"github.com/tygermarshall/blunderbuss/shared/pieces"
 )
 
 var gameStore = NewGameStore()
@@ -22,6 +28,9 @@ var wsUpgrader = websocket.Upgrader{
 	},
 }
 
+// handleWebSocket plays moves sent as JSON wsMoveRequest messages, e.g.
+// {"gameId": "...", "move": "e2e4"}, answering each with a
+// shared.MoveResponse or a shared.ErrorResponse.
 func handleWebSocket(c *gin.Context) {
 	conn, err := wsUpgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
@@ -30,14 +39,23 @@ func handleWebSocket(c *gin.Context) {
 	}
 	defer conn.Close()
 	for {
-		mt, msg, err := conn.ReadMessage()
+		_, msg, err := conn.ReadMessage()
 		if err != nil {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
 				log.Printf("websocket read: %v", err)
 			}
 			break
 		}
-		if err := conn.WriteMessage(mt, msg); err != nil {
+		var reply any
+		var req wsMoveRequest
+		if err := json.Unmarshal(msg, &req); err != nil {
+			_, reply = errorResponse(fmt.Errorf("%w: %v", ErrInvalidRequest, err))
+		} else if res, err := playMove(req.GameId, req.moveRequest); err != nil {
+			_, reply = errorResponse(err)
+		} else {
+			reply = res
+		}
+		if err := conn.WriteJSON(reply); err != nil {
 			log.Printf("websocket write: %v", err)
 			break
 		}
@@ -46,51 +64,83 @@ func handleWebSocket(c *gin.Context) {
 
 func movePiece(c *gin.Context) {
 	id := c.Param("id")
-	//var req moveRequest
-	//if err := c.ShouldBindJSON(&req); err != nil {
-	//	c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request: " + err.Error()})
-	//	return
-	//}
-	//err := gameStore.Move(id, req.From, req.To)
-	//todo move this seeing if works
-	//end todo
-	//if err == ErrGameNotFound {
-	//	c.JSON(http.StatusNotFound, gin.H{"error": "game not found"})
-	//	return
-	//}
-	//if err != nil {
-	//	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-	//	return
-	//	}
-
-	gameStore.Move(id, board.Coordinate{X: 6, Y: 0}, board.Coordinate{X: 4, Y: 0})
-	g, ok := gameStore.Get(id)
-
-	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get game"})
+	var req moveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeErr(c, fmt.Errorf("%w: %v", ErrInvalidRequest, err))
+		return
+	}
+	body, err := playMove(id, req)
+	if err != nil {
+		writeErr(c, err)
+		return
 	}
-	g.Board.MoveCount += 1
-	log.Printf("move count is: %d", g.Board.MoveCount)
-	body := shared.CreateGameReponse{GameId: id, Board: g.Board}
 
 	var buf bytes.Buffer
 	if err := json.NewEncoder(&buf).Encode(body); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to serialize game"})
+		writeError(c, http.StatusInternalServerError, codeInternal, "failed to serialize game")
 		return
 	}
 
 	c.Data(http.StatusCreated, "application/json", buf.Bytes())
 }
 
+// playMove plays the requested move in the game with the given ID.
+func playMove(id string, req moveRequest) (shared.MoveResponse, error) {
+	m, err := req.move()
+	if err != nil {
+		return shared.MoveResponse{}, err
+	}
+	applied, g, err := gameStore.Move(id, m)
+	if err != nil {
+		return shared.MoveResponse{}, err
+	}
+	return shared.MoveResponse{GameId: id, Board: g.Board, Move: applied, UCI: applied.UCI()}, nil
+}
+
 func startNewGame(c *gin.Context) {
-	id, err := gameStore.Create()
+	var req newGameRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeErr(c, fmt.Errorf("%w: %v", ErrInvalidRequest, err))
+		return
+	}
+	if req.FEN != "" && req.PGN != "" {
+		writeErr(c, fmt.Errorf("%w: fen and pgn cannot both be given", ErrInvalidRequest))
+		return
+	}
+	start := board.CreateDefaultBoard()
+	var moves []board.Move
+	result := ResultOngoing
+	switch {
+	case req.FEN != "":
+		var err error
+		if start, err = board.ParseFEN(req.FEN); err != nil {
+			writeErr(c, err)
+			return
+		}
+	case req.PGN != "":
+		pg, err := pgn.NewReader(strings.NewReader(req.PGN)).Next()
+		if errors.Is(err, io.EOF) {
+			err = errors.New("no game found")
+		}
+		if err != nil {
+			writeErr(c, fmt.Errorf("%w: %w", ErrInvalidPGN, err))
+			return
+		}
+		start, moves, result = pg.StartBoard(), pg.Mainline(), gameResult(pg.Result)
+	}
+	id, err := gameStore.Import(start, moves, result)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create game"})
+		var illegalErr *board.IllegalMoveError
+		if errors.As(err, &illegalErr) || errors.Is(err, ErrResultConflict) {
+			writeErr(c, err)
+			return
+		}
+		writeError(c, http.StatusInternalServerError, codeInternal, "failed to create game")
 		return
 	}
 	g, ok := gameStore.Get(id)
 	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create game"})
+		writeError(c, http.StatusInternalServerError, codeInternal, "failed to create game")
 		return
 	}
 
@@ -100,7 +150,7 @@ func startNewGame(c *gin.Context) {
 
 	var buf bytes.Buffer
 	if err := json.NewEncoder(&buf).Encode(body); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to serialize game"})
+		writeError(c, http.StatusInternalServerError, codeInternal, "failed to serialize game")
 		return
 	}
 	c.Data(http.StatusCreated, "application/json", buf.Bytes())
@@ -110,19 +160,114 @@ func getGame(c *gin.Context) {
 	id := c.Param("id")
 	g, ok := gameStore.Get(id)
 	if !ok {
-		c.JSON(http.StatusNotFound, gin.H{"error": "game not found"})
+		writeErr(c, ErrGameNotFound)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"gameId":       id,
+		"turnNumber":   g.TurnNumber(),
+		"sideToMove":   g.Board.SideToMove.String(),
+		"board":        g.Board.Squares(),
+		"fen":          g.Board.FEN(),
+		"result":       g.Result,
+		"reason":       g.Reason,
+		"moves":        uciMoves(g.Moves),
+		"repetitions":  g.Repetitions(),
+		"canClaimDraw": g.CanClaimDraw(),
+	})
+}
+
+// claimDraw ends a game as a draw by threefold repetition or the
+// fifty-move rule on behalf of the side to move.
+func claimDraw(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := gameStore.ClaimDraw(id); err != nil {
+		writeErr(c, err)
+		return
+	}
+	g, ok := gameStore.Get(id)
+	if !ok {
+		writeErr(c, ErrGameNotFound)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{
-		"gameId":     id,
-		"turnNumber": g.TurnNumber,
-		"board":      g.Board.Squares,
+		"gameId": id,
+		"result": g.Result,
+		"reason": g.Reason,
 	})
 }
 
+// uciMoves writes moves in UCI long algebraic form.
+func uciMoves(moves []board.Move) []string {
+	out := make([]string, len(moves))
+	for i, m := range moves {
+		out[i] = m.UCI()
+	}
+	return out
+}
+
+// getGamePGN exports a game as PGN text.
+func getGamePGN(c *gin.Context) {
+	id := c.Param("id")
+	g, ok := gameStore.Get(id)
+	if !ok {
+		writeErr(c, ErrGameNotFound)
+		return
+	}
+	pg, err := g.PGN(id)
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, codeInternal, "failed to export game")
+		return
+	}
+	c.Data(http.StatusOK, "application/x-chess-pgn", []byte(pg.String()))
+}
+
+// newGameRequest is the optional body of POST /games. An empty body or
+// FEN starts from the standard position. PGN imports the first game of a
+// PGN text, starting position and moves included; it cannot be combined
+// with FEN.
+type newGameRequest struct {
+	FEN string `json:"fen"`
+	PGN string `json:"pgn"`
+}
+
+// moveRequest is a move as a UCI string, e.g. {"move": "e7e8q"}, or as
+// squares named algebraically, e.g. {"from": "e2", "to": "e4"}. Move wins
+// if both are given. From and To are pointers so that a missing square is
+// an error rather than a1.
 type moveRequest struct {
-	From board.Coordinate `json:"from"`
-	To   board.Coordinate `json:"to"`
+	Move string        `json:"move,omitempty"`
+	From *board.Square `json:"from,omitempty"`
+	To   *board.Square `json:"to,omitempty"`
+	// Promotion names the piece a pawn reaching the last rank becomes,
+	// e.g. "queen" or "q".
+	Promotion string `json:"promotion,omitempty"`
+}
+
+// move returns the requested move. A request with neither a move nor
+// both squares wraps ErrInvalidRequest.
+func (r moveRequest) move() (board.Move, error) {
+	if r.Move != "" {
+		return board.ParseUCI(r.Move)
+	}
+	if r.From == nil || r.To == nil {
+		return board.Move{}, fmt.Errorf("%w: move or both from and to are required", ErrInvalidRequest)
+	}
+	m := board.NewMove(*r.From, *r.To)
+	if r.Promotion != "" {
+		pt, err := pieces.ParsePieceType(r.Promotion)
+		if err != nil {
+			return m, fmt.Errorf("%w: %v", ErrInvalidRequest, err)
+		}
+		m.Promotion = pt
+	}
+	return m, nil
+}
+
+// wsMoveRequest is a move sent over the WebSocket, naming its game.
+type wsMoveRequest struct {
+	GameId string `json:"gameId"`
+	moveRequest
 }
 
 func main() {
@@ -134,7 +279,9 @@ func main() {
 	})
 	router.POST("/games", startNewGame)
 	router.GET("/games/:id", getGame)
+	router.GET("/games/:id/pgn", getGamePGN)
 	router.POST("/games/:id/move", movePiece)
+	router.POST("/games/:id/draw", claimDraw)
 	router.GET("/ws", handleWebSocket)
 	router.Run() // listens on 0.0.0.0:8080 by default
 }
@@ -146,13 +293,13 @@ func hold() {
 	shared.PrintBoard(&gameBoard)
 
 	shared.PrettyPrint(gameBoard)
-	gameBoard, err := gameBoard.MovePiece(board.Coordinate{X: 6, Y: 0}, board.Coordinate{X: 4, Y: 0})
+	gameBoard, _, err := gameBoard.MovePiece(board.NewMove(board.A2, board.A4))
 	if err != nil {
 		log.Fatal(err)
 	}
 	shared.PrettyPrint(gameBoard)
 
-	gameBoard, err = gameBoard.MovePiece(board.Coordinate{X: 1, Y: 4}, board.Coordinate{X: 0, Y: 4})
+	gameBoard, _, err = gameBoard.MovePiece(board.NewMove(board.E7, board.E8))
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tygermarshall/blunderbuss/shared"
+	"github.com/tygermarshall/blunderbuss/shared/board"
+)
+
+// TestMoveRequest posts move requests to POST /games/:id/move, each to a
+// new game, and checks the move played or the error reported.
+func TestMoveRequest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/games/:id/move", movePiece)
+
+	const promotionFEN = "4k3/P7/8/8/8/8/8/4K3 w - - 0 1"
+	tests := []struct {
+		name   string
+		fen    string
+		body   string
+		status int
+		// uci is the move played on success, and code the error code
+		// otherwise
+		uci, code string
+	}{
+		{"UCI", board.StartFEN, `{"move": "e2e4"}`, http.StatusCreated, "e2e4", ""},
+		{"UCI promotion", promotionFEN, `{"move": "a7a8n"}`, http.StatusCreated, "a7a8n", ""},
+		{"squares", board.StartFEN, `{"from": "g1", "to": "f3"}`, http.StatusCreated, "g1f3", ""},
+		{"squares and promotion", promotionFEN, `{"from": "a7", "to": "a8", "promotion": "queen"}`, http.StatusCreated, "a7a8q", ""},
+		{"squares and promotion letter", promotionFEN, `{"from": "a7", "to": "a8", "promotion": "r"}`, http.StatusCreated, "a7a8r", ""},
+		{"both forms", board.StartFEN, `{"move": "d2d4", "from": "e2", "to": "e4"}`, http.StatusCreated, "d2d4", ""},
+		{"neither form", board.StartFEN, `{}`, http.StatusBadRequest, "", "invalid_request"},
+		{"no to square", board.StartFEN, `{"from": "e2"}`, http.StatusBadRequest, "", "invalid_request"},
+		{"bad square", board.StartFEN, `{"from": "e9", "to": "e4"}`, http.StatusBadRequest, "", "invalid_request"},
+		{"bad promotion", promotionFEN, `{"from": "a7", "to": "a8", "promotion": "dragon"}`, http.StatusBadRequest, "", "invalid_request"},
+		{"not JSON", board.StartFEN, `e2e4`, http.StatusBadRequest, "", "invalid_request"},
+		{"malformed UCI", board.StartFEN, `{"move": "e2e9"}`, http.StatusBadRequest, "", "invalid_uci"},
+		{"UCI promotion to a king", promotionFEN, `{"move": "a7a8k"}`, http.StatusBadRequest, "", "invalid_uci"},
+		{"promotion missing", promotionFEN, `{"move": "a7a8"}`, http.StatusBadRequest, "", "invalid_promotion"},
+		{"wrong turn", board.StartFEN, `{"move": "e7e5"}`, http.StatusConflict, "", "wrong_turn"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id := importFEN(t, gameStore, tt.fen)
+			req := httptest.NewRequest(http.MethodPost, "/games/"+id+"/move", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Fatalf("status %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			if tt.code != "" {
+				var body shared.ErrorResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+					t.Fatal(err)
+				}
+				if body.Code != tt.code {
+					t.Errorf("code %q, want %q: %s", body.Code, tt.code, body.Message)
+				}
+				return
+			}
+			var body shared.MoveResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body.UCI != tt.uci || body.GameId != id {
+				t.Errorf("played %s in %s, want %s in %s", body.UCI, body.GameId, tt.uci, id)
+			}
+		})
+	}
+}
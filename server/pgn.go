@@ -0,0 +1,48 @@
+package main
+
+import (
+	"github.com/tygermarshall/blunderbuss/shared/pgn"
+)
+
+// PGN exports the game with the given ID in Portable Game Notation.
+func (g *Game) PGN(id string) (*pgn.Game, error) {
+	pg := pgn.NewGame()
+	pg.SetTag("Event", "Blunderbuss game")
+	pg.SetTag("Site", "Blunderbuss")
+	pg.SetTag("Date", g.Created.Format("2006.01.02"))
+	pg.SetTag("Round", "-")
+	pg.Result = pgnResult(g.Result)
+	pg.SetTag("Result", pg.Result)
+	pg.SetTag("GameId", id)
+	pg.SetStartBoard(g.Start)
+	if err := pg.AppendMoves(g.Moves...); err != nil {
+		return nil, err
+	}
+	return pg, nil
+}
+
+func pgnResult(r GameResult) string {
+	switch r {
+	case ResultWhiteWins:
+		return pgn.ResultWhiteWins
+	case ResultBlackWins:
+		return pgn.ResultBlackWins
+	case ResultDraw:
+		return pgn.ResultDraw
+	}
+	return pgn.ResultUnknown
+}
+
+// gameResult is the inverse of pgnResult. Anything but a finished result
+// is ResultOngoing.
+func gameResult(r string) GameResult {
+	switch r {
+	case pgn.ResultWhiteWins:
+		return ResultWhiteWins
+	case pgn.ResultBlackWins:
+		return ResultBlackWins
+	case pgn.ResultDraw:
+		return ResultDraw
+	}
+	return ResultOngoing
+}
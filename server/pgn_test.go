@@ -0,0 +1,104 @@
+package main
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/tygermarshall/blunderbuss/shared/board"
+	"github.com/tygermarshall/blunderbuss/shared/pgn"
+)
+
+// importPGN imports the first game of text into s, as POST /games does.
+func importPGN(s *GameStore, text string) (string, error) {
+	pg, err := pgn.NewReader(strings.NewReader(text)).Next()
+	if err != nil {
+		return "", err
+	}
+	return s.Import(pg.StartBoard(), pg.Mainline(), gameResult(pg.Result))
+}
+
+// TestPGNRoundTrip exports stored games as PGN text and imports the text
+// again, which must give back the same game.
+func TestPGNRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		fen    string
+		moves  []string
+		result GameResult
+	}{
+		{"ongoing", board.StartFEN, []string{"e2e4", "c7c5", "g1f3", "d7d6", "d2d4", "c5d4", "f3d4"}, ResultOngoing},
+		{"checkmate", board.StartFEN, []string{"f2f3", "e7e5", "g2g4", "d8h4"}, ResultOngoing},
+		{"castling, en passant and promotion", "r3k2r/P7/8/8/5p2/8/4P3/R3K2R w KQkq - 0 30",
+			[]string{"e1g1", "e8c8", "e2e4", "f4e3", "a7a8n", "e3e2"}, ResultOngoing},
+		{"recorded result", "4k3/8/8/8/8/8/8/R3K3 b - - 3 41", []string{"e8d7", "a1a7"}, ResultWhiteWins},
+		{"no moves", board.StartFEN, nil, ResultDraw},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewGameStore()
+			start, err := board.ParseFEN(tt.fen)
+			if err != nil {
+				t.Fatal(err)
+			}
+			moves := make([]board.Move, len(tt.moves))
+			for i, uci := range tt.moves {
+				if moves[i], err = board.ParseUCI(uci); err != nil {
+					t.Fatal(err)
+				}
+			}
+			id, err := s.Import(start, moves, tt.result)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, _ := s.Get(id)
+			pg, err := want.PGN(id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			text := pg.String()
+
+			id2, err := importPGN(s, text)
+			if err != nil {
+				t.Fatalf("importing\n%s: %v", text, err)
+			}
+			got, _ := s.Get(id2)
+			if got.Start != want.Start {
+				t.Errorf("start %s, want %s", got.Start.FEN(), want.Start.FEN())
+			}
+			if !slices.Equal(got.Moves, want.Moves) {
+				t.Errorf("moves %v, want %v", uciMoves(got.Moves), uciMoves(want.Moves))
+			}
+			if got.Board != want.Board || got.Result != want.Result || got.Reason != want.Reason {
+				t.Errorf("ends in %s, %s by %q, want %s, %s by %q", got.Board.FEN(), got.Result, got.Reason, want.Board.FEN(), want.Result, want.Reason)
+			}
+			pg2, err := got.PGN(id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			date, _ := pg.Tag("Date")
+			pg2.SetTag("Date", date)
+			if text2 := pg2.String(); text2 != text {
+				t.Errorf("exported again as\n%s\nwant\n%s", text2, text)
+			}
+		})
+	}
+}
+
+func TestImportResultConflict(t *testing.T) {
+	s := NewGameStore()
+	_, err := importPGN(s, "1. f3 e5 2. g4 Qh4# 1-0\n")
+	if !errors.Is(err, ErrResultConflict) {
+		t.Fatalf("got %v, want ErrResultConflict", err)
+	}
+	if len(s.games) != 0 {
+		t.Error("the conflicting game was stored")
+	}
+
+	// the marker wins over the Result tag, so this one agrees with the
+	// position
+	if _, err := importPGN(s, "[Result \"1-0\"]\n\n1. f3 e5 2. g4 Qh4# 0-1\n"); err != nil {
+		t.Errorf("got %v", err)
+	}
+}
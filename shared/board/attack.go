@@ -0,0 +1,38 @@
+package board
+
+import (
+	"github.com/tygermarshall/blunderbuss/shared/pieces"
+)
+
+// Offsets are written as {files, ranks}.
+var (
+	knightOffsets   = [8][2]int{{-2, -1}, {-2, 1}, {-1, -2}, {-1, 2}, {1, -2}, {1, 2}, {2, -1}, {2, 1}}
+	kingOffsets     = [8][2]int{{-1, -1}, {-1, 0}, {-1, 1}, {0, -1}, {0, 1}, {1, -1}, {1, 0}, {1, 1}}
+	straightOffsets = [4][2]int{{-1, 0}, {1, 0}, {0, -1}, {0, 1}}
+	diagonalOffsets = [4][2]int{{-1, -1}, {-1, 1}, {1, -1}, {1, 1}}
+)
+
+// isAttacked reports whether any piece of team by attacks sq. Pieces
+// attack a square whether or not it is occupied.
+func (b *Board) isAttacked(sq Square, by pieces.Team) bool {
+	return b.attackersOf(sq, by, b.Occupied()) != 0
+}
+
+// attackersOf returns the squares of by's pieces that attack sq when the
+// squares in occupied are the ones that block sliders.
+func (b *Board) attackersOf(sq Square, by pieces.Team, occupied Bitboard) Bitboard {
+	own := &b.pieceBB[by]
+	// a pawn of by attacks sq from where an enemy pawn on sq would attack
+	attackers := pawnAttacks[by.Opponent()][sq] & own[pieces.Pawn]
+	attackers |= knightAttacks[sq] & own[pieces.Knight]
+	attackers |= kingAttacks[sq] & own[pieces.King]
+	attackers |= BishopAttacks(sq, occupied) & (own[pieces.Bishop] | own[pieces.Queen])
+	attackers |= RookAttacks(sq, occupied) & (own[pieces.Rook] | own[pieces.Queen])
+	return attackers
+}
+
+// hasPiece reports whether sq holds a piece of the given type and team.
+// NoSquare holds nothing.
+func (b *Board) hasPiece(sq Square, pt pieces.PieceType, team pieces.Team) bool {
+	return sq.Valid() && b.mailbox[sq] == pieceCode(pt, team)
+}
@@ -0,0 +1,222 @@
+package board
+
+import (
+	"math/bits"
+
+	"github.com/tygermarshall/blunderbuss/shared/pieces"
+)
+
+// Bitboard is a set of squares, bit n standing for Square(n).
+type Bitboard uint64
+
+func squareBB(sq Square) Bitboard {
+	return 1 << uint(sq)
+}
+
+// Has reports whether sq is in bb.
+func (bb Bitboard) Has(sq Square) bool {
+	return bb&squareBB(sq) != 0
+}
+
+// Count returns the number of squares in bb.
+func (bb Bitboard) Count() int {
+	return bits.OnesCount64(uint64(bb))
+}
+
+// First returns the lowest square in bb, which must not be empty.
+func (bb Bitboard) First() Square {
+	return Square(bits.TrailingZeros64(uint64(bb)))
+}
+
+// Pop removes the lowest square from bb and returns it.
+func (bb *Bitboard) Pop() Square {
+	sq := bb.First()
+	*bb &= *bb - 1
+	return sq
+}
+
+const (
+	rank1BB Bitboard = 0xff
+	rank8BB Bitboard = rank1BB << 56
+	fileABB Bitboard = 0x0101010101010101
+	fileHBB Bitboard = fileABB << 7
+
+	lightSquares Bitboard = 0x55aa55aa55aa55aa
+)
+
+// Attack tables for the pieces whose moves do not depend on other pieces.
+// pawnAttacks is indexed by team.
+var (
+	knightAttacks [64]Bitboard
+	kingAttacks   [64]Bitboard
+	pawnAttacks   [2][64]Bitboard
+)
+
+func init() {
+	for sq := A1; sq <= H8; sq++ {
+		for _, o := range knightOffsets {
+			if to := sq.Offset(o[0], o[1]); to != NoSquare {
+				knightAttacks[sq] |= squareBB(to)
+			}
+		}
+		for _, o := range kingOffsets {
+			if to := sq.Offset(o[0], o[1]); to != NoSquare {
+				kingAttacks[sq] |= squareBB(to)
+			}
+		}
+		for team := range pawnAttacks {
+			dir := 1 - 2*team
+			for _, df := range [2]int{-1, 1} {
+				if to := sq.Offset(df, dir); to != NoSquare {
+					pawnAttacks[team][sq] |= squareBB(to)
+				}
+			}
+		}
+	}
+	initMagics(&rookMagics, &rookMagicNumbers, straightOffsets)
+	initMagics(&bishopMagics, &bishopMagicNumbers, diagonalOffsets)
+}
+
+// slideAttacks walks from sq along each direction in offsets up to and
+// including the first occupied square. It is the slow reference the magic
+// tables are built from.
+func slideAttacks(sq Square, occupied Bitboard, offsets [4][2]int) Bitboard {
+	var attacks Bitboard
+	for _, o := range offsets {
+		for to := sq.Offset(o[0], o[1]); to != NoSquare; to = to.Offset(o[0], o[1]) {
+			attacks |= squareBB(to)
+			if occupied.Has(to) {
+				break
+			}
+		}
+	}
+	return attacks
+}
+
+// magic finds a slider's attacks from one square with a single lookup:
+// the occupied squares that can block it are masked, multiplied by a
+// magic number that packs them into the top bits, and the result shifted
+// down to index a table of attack sets.
+type magic struct {
+	mask    Bitboard
+	number  uint64
+	shift   uint
+	attacks []Bitboard
+}
+
+func (m *magic) index(occupied Bitboard) uint64 {
+	return uint64(occupied&m.mask) * m.number >> m.shift
+}
+
+var rookMagics, bishopMagics [64]magic
+
+// KnightAttacks returns the squares a knight on sq attacks.
+func KnightAttacks(sq Square) Bitboard {
+	return knightAttacks[sq]
+}
+
+// KingAttacks returns the squares a king on sq attacks.
+func KingAttacks(sq Square) Bitboard {
+	return kingAttacks[sq]
+}
+
+// PawnAttacks returns the squares a pawn of team on sq attacks.
+func PawnAttacks(team pieces.Team, sq Square) Bitboard {
+	return pawnAttacks[team][sq]
+}
+
+// RookAttacks returns the squares a rook on sq attacks when the squares in
+// occupied hold pieces: along each line up to and including the first
+// occupied square.
+func RookAttacks(sq Square, occupied Bitboard) Bitboard {
+	m := &rookMagics[sq]
+	return m.attacks[m.index(occupied)]
+}
+
+// BishopAttacks is RookAttacks for a bishop.
+func BishopAttacks(sq Square, occupied Bitboard) Bitboard {
+	m := &bishopMagics[sq]
+	return m.attacks[m.index(occupied)]
+}
+
+// QueenAttacks is RookAttacks for a queen.
+func QueenAttacks(sq Square, occupied Bitboard) Bitboard {
+	return RookAttacks(sq, occupied) | BishopAttacks(sq, occupied)
+}
+
+// initMagics fills in the mask, shift and attack table for every square
+// from its magic number, which must map each blocker set to a slot holding
+// its attack set, sharing slots only between blocker sets with the same
+// attacks.
+func initMagics(magics *[64]magic, numbers *[64]uint64, offsets [4][2]int) {
+	for sq := A1; sq <= H8; sq++ {
+		m := &magics[sq]
+		// edge squares never block anything beyond themselves
+		edges := (rank1BB|rank8BB)&^RankBB(sq.Rank()) | (fileABB|fileHBB)&^FileBB(sq.File())
+		m.mask = slideAttacks(sq, 0, offsets) &^ edges
+		m.number = numbers[sq]
+		m.shift = uint(64 - m.mask.Count())
+		m.attacks = make([]Bitboard, 1<<m.mask.Count())
+
+		// enumerate every subset of the mask with the carry-rippler trick
+		for subset := Bitboard(0); ; {
+			m.attacks[m.index(subset)] = slideAttacks(sq, subset, offsets)
+			subset = (subset - m.mask) & m.mask
+			if subset == 0 {
+				break
+			}
+		}
+	}
+}
+
+// The magic numbers were found by trying sparse random numbers until one
+// sent no two blocker sets with different attacks to the same slot.
+// Searching takes most of a second, so they are kept here rather than
+// found at startup.
+var rookMagicNumbers = [64]uint64{
+	0x1080004008801020, 0x0840092002c03000, 0x1900200010400900, 0x0880100008000480,
+	0x4200100420080200, 0x8100020100080400, 0x0200040110886200, 0x0200008040220411,
+	0x0404800084400220, 0x0000401000402000, 0x0086001081220440, 0x0408800800100280,
+	0x000a001201040820, 0x8848800200840080, 0x4001000100040200, 0x0442000102105084,
+	0x9080010020804100, 0x0040404000201009, 0x0000808010002009, 0x2200090021d00100,
+	0x0008008008040080, 0x0004004002010040, 0x0011040008015042, 0x00000a0001768104,
+	0x0000800080204009, 0x2010004140002001, 0x9800200280100080, 0x1000100080080080,
+	0x0442000a00049020, 0x2100040080020080, 0x0800120400900148, 0x0010040a00128541,
+	0x2800804000800030, 0x1010002000400041, 0x4000200011004100, 0x0610008410800800,
+	0x0400802402800800, 0xc100020080800400, 0x0002000802000401, 0x0182085882000401,
+	0x0220204000808000, 0x2860100040024022, 0x0001002004110040, 0x99101042000a0020,
+	0x0004080004008080, 0x0010040002008080, 0x2012004881020004, 0x8300842444820011,
+	0x0088403882010200, 0x0820400080210100, 0x0110910040a00300, 0x0801100280080480,
+	0x0242009008200600, 0x1002000489500200, 0x0040800200010080, 0x0091800041000080,
+	0x0000209300488001, 0x04c1002414824001, 0x020020000b001041, 0x7000100004200901,
+	0x8002002004100802, 0x30010002084c0007, 0x0888221800813004, 0x4000002840840112,
+}
+
+var bishopMagicNumbers = [64]uint64{
+	0x10102002004a1420, 0x8020040400584008, 0x10510800811201c8, 0x5204042080000088,
+	0x2204106880000002, 0x1401042004000000, 0x0400880410042004, 0x0028208200a02020,
+	0x1500241990010e00, 0x8001200182020a40, 0x40004101030b0000, 0x8002041042000100,
+	0x4010011041020038, 0x0000010421044000, 0x1500210808020a00, 0x8000088400880520,
+	0x0405004010040100, 0x1005823210040108, 0x2708008102040011, 0x4048200404009100,
+	0x0018104101400024, 0x0003000601190101, 0x8004803108491000, 0x8014241200820800,
+	0x0006e080100c3040, 0x0501044a11041800, 0x9020300008004045, 0x0894080000220040,
+	0x1001010083104000, 0x5004030040900080, 0x000400422c012400, 0x0002128698404812,
+	0x1010108404900440, 0x0928021182084100, 0x2006080409020024, 0x1010202020180080,
+	0xa010008200202200, 0x2098015100019004, 0x0002041440810811, 0x802a02020000b098,
+	0x0009015090004060, 0x4000821082081001, 0x0100210040420800, 0x0800004010488a00,
+	0x2000081104004040, 0x4c8e029015000082, 0x0420340322224842, 0x1298260043400210,
+	0x0000822802400008, 0x00008a0101600000, 0x3040003412080021, 0x3040290220884800,
+	0x4a1500401041004a, 0x8010200282020781, 0x0020203142209091, 0x0070300600902110,
+	0x0040808800b62048, 0x0000810400c44420, 0x00080400440c0441, 0x8340080020840411,
+	0x0000000104208200, 0x0000800810d00080, 0x0400530411080200, 0x4040702400932244,
+}
+
+// RankBB returns the squares of a rank, 0 to 7.
+func RankBB(rank int) Bitboard {
+	return rank1BB << (8 * uint(rank))
+}
+
+// FileBB returns the squares of a file, 0 to 7.
+func FileBB(file int) Bitboard {
+	return fileABB << uint(file)
+}
@@ -0,0 +1,199 @@
+package board
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/tygermarshall/blunderbuss/shared/pieces"
+)
+
+// rayAttacks is the slow way to find a slider's attacks: step square by
+// square along each direction in file and rank coordinates until the edge
+// of the board or an occupied square.
+func rayAttacks(sq Square, occupied Bitboard, dirs [4][2]int) Bitboard {
+	var attacks Bitboard
+	for _, d := range dirs {
+		f, r := sq.File()+d[0], sq.Rank()+d[1]
+		for f >= 0 && f < 8 && r >= 0 && r < 8 {
+			to := Square(r*8 + f)
+			attacks |= squareBB(to)
+			if occupied.Has(to) {
+				break
+			}
+			f, r = f+d[0], r+d[1]
+		}
+	}
+	return attacks
+}
+
+var (
+	rookDirs   = [4][2]int{{1, 0}, {-1, 0}, {0, 1}, {0, -1}}
+	bishopDirs = [4][2]int{{1, 1}, {1, -1}, {-1, 1}, {-1, -1}}
+)
+
+// subsets calls fn with every subset of mask.
+func subsets(mask Bitboard, fn func(Bitboard)) {
+	for subset := Bitboard(0); ; {
+		fn(subset)
+		subset = (subset - mask) & mask
+		if subset == 0 {
+			return
+		}
+	}
+}
+
+// TestSliderAttacks checks the magic lookups against rayAttacks from every
+// square for every set of blockers that matters, each time also with
+// random pieces on squares that do not.
+func TestSliderAttacks(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	sliders := []struct {
+		name    string
+		attacks func(Square, Bitboard) Bitboard
+		magics  *[64]magic
+		dirs    [4][2]int
+	}{
+		{"rook", RookAttacks, &rookMagics, rookDirs},
+		{"bishop", BishopAttacks, &bishopMagics, bishopDirs},
+	}
+	for _, s := range sliders {
+		t.Run(s.name, func(t *testing.T) {
+			for sq := A1; sq <= H8; sq++ {
+				mask := s.magics[sq].mask
+				subsets(mask, func(blockers Bitboard) {
+					want := rayAttacks(sq, blockers, s.dirs)
+					noise := Bitboard(rng.Uint64()) &^ mask
+					for _, occupied := range []Bitboard{blockers, blockers | noise} {
+						if got := s.attacks(sq, occupied); got != want {
+							t.Fatalf("%s with %#x occupied: got %#x, want %#x", sq, uint64(occupied), uint64(got), uint64(want))
+						}
+					}
+				})
+			}
+		})
+	}
+}
+
+// TestMagicNumbers checks that no magic number sends two blocker sets with
+// different attacks to the same slot, and that the tables are no bigger
+// than the masks need.
+func TestMagicNumbers(t *testing.T) {
+	for _, s := range []struct {
+		name   string
+		magics *[64]magic
+		dirs   [4][2]int
+	}{
+		{"rook", &rookMagics, rookDirs},
+		{"bishop", &bishopMagics, bishopDirs},
+	} {
+		for sq := A1; sq <= H8; sq++ {
+			m := &s.magics[sq]
+			if len(m.attacks) != 1<<m.mask.Count() {
+				t.Errorf("%s on %s: %d slots for a %d square mask", s.name, sq, len(m.attacks), m.mask.Count())
+			}
+			slots := make(map[uint64]Bitboard)
+			subsets(m.mask, func(blockers Bitboard) {
+				i := m.index(blockers)
+				want := rayAttacks(sq, blockers, s.dirs)
+				if got, ok := slots[i]; ok && got != want {
+					t.Fatalf("%s on %s: blockers %#x collide in slot %d", s.name, sq, uint64(blockers), i)
+				}
+				slots[i] = want
+			})
+		}
+	}
+}
+
+// BenchmarkSliderAttacks compares finding the attacks of every slider in
+// the reference positions with the magic tables against walking the rays
+// of a [64]pieces.Piece array, which is how the board found them before
+// it kept bitboards. Run with
+//
+//	go test -run '^$' -bench SliderAttacks ./board
+func BenchmarkSliderAttacks(b *testing.B) {
+	type slider struct {
+		sq     Square
+		bishop bool
+		rook   bool
+	}
+	type position struct {
+		squares  [64]pieces.Piece
+		occupied Bitboard
+		sliders  []slider
+	}
+	var positions []position
+	for _, p := range PerftPositions {
+		board, err := ParseFEN(p.FEN)
+		if err != nil {
+			b.Fatal(err)
+		}
+		var pos position
+		for sq := A1; sq <= H8; sq++ {
+			piece := board.PieceAt(sq)
+			pos.squares[sq] = piece
+			if piece.Type == pieces.Empty {
+				continue
+			}
+			pos.occupied |= squareBB(sq)
+			switch piece.Type {
+			case pieces.Bishop, pieces.Rook, pieces.Queen:
+				pos.sliders = append(pos.sliders, slider{
+					sq:     sq,
+					bishop: piece.Type != pieces.Rook,
+					rook:   piece.Type != pieces.Bishop,
+				})
+			}
+		}
+		positions = append(positions, pos)
+	}
+
+	var sink Bitboard
+	b.Run("magic", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for p := range positions {
+				pos := &positions[p]
+				for _, s := range pos.sliders {
+					if s.rook {
+						sink |= RookAttacks(s.sq, pos.occupied)
+					}
+					if s.bishop {
+						sink |= BishopAttacks(s.sq, pos.occupied)
+					}
+				}
+			}
+		}
+	})
+	b.Run("array", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for p := range positions {
+				pos := &positions[p]
+				for _, s := range pos.sliders {
+					if s.rook {
+						sink |= arrayAttacks(&pos.squares, s.sq, rookDirs)
+					}
+					if s.bishop {
+						sink |= arrayAttacks(&pos.squares, s.sq, bishopDirs)
+					}
+				}
+			}
+		}
+	})
+	_ = sink
+}
+
+// arrayAttacks walks the rays from sq over an array board.
+func arrayAttacks(squares *[64]pieces.Piece, sq Square, dirs [4][2]int) Bitboard {
+	var attacks Bitboard
+	for _, d := range dirs {
+		f, r := sq.File()+d[0], sq.Rank()+d[1]
+		for f >= 0 && f < 8 && r >= 0 && r < 8 {
+			to := r*8 + f
+			attacks |= squareBB(Square(to))
+			if squares[to].Type != pieces.Empty {
+				break
+			}
+			f, r = f+d[0], r+d[1]
+		}
+	}
+	return attacks
+}
@@ -1,136 +1,489 @@
 package board
 
 import (
+	"encoding/json"
 	"errors"
-	"fmt"
+
 	"github.com/tygermarshall/blunderbuss/shared/pieces"
 )
 
+// Board is a chess position. The pieces are kept as bitboards, one set of
+// squares for each team and piece type, with a byte per square alongside
+// for lookups by square, so a Board is small enough to copy cheaply.
+// PieceAt and Squares give the pieces square by square.
 type Board struct {
-	Squares   [8][8]pieces.Piece `json:"Squares"`
-	MoveCount int                `json:"move_count"`
+	// SideToMove is the team whose turn it is.
+	SideToMove pieces.Team `json:"side_to_move"`
+	// HalfmoveClock counts moves since the last capture or pawn move.
+	HalfmoveClock int `json:"halfmove_clock"`
+	// FullmoveNumber starts at 1 and goes up after each black move.
+	FullmoveNumber int `json:"fullmove_number"`
+	// Castling records which castling moves each side may still make.
+	Castling CastlingRights `json:"castling"`
+	// EnPassant is the square a pawn skipped over with a double push on the
+	// previous move, or NoSquare if the last move was not a double push.
+	EnPassant Square `json:"en_passant"`
+
+	// pieceBB holds the squares of each team's pieces of each type.
+	pieceBB [2][6]Bitboard
+	// teamBB holds the squares of all of each team's pieces.
+	teamBB [2]Bitboard
+	// mailbox holds the pieceCode of the piece on each square.
+	mailbox [64]uint8
+	// hash is the Zobrist key returned by Hash.
+	hash uint64
+}
+
+// pieceCode packs a piece's type and team into a byte, 0 meaning an empty
+// square.
+func pieceCode(pt pieces.PieceType, team pieces.Team) uint8 {
+	return uint8(pt)<<1 | uint8(team) + 1
+}
+
+var emptyPiece = pieces.Piece{Type: pieces.Empty, Team: pieces.Neutral}
+
+func decodePiece(code uint8) pieces.Piece {
+	if code == 0 {
+		return emptyPiece
+	}
+	return pieces.Piece{Type: pieces.PieceType((code - 1) >> 1), Team: pieces.Team((code - 1) & 1)}
+}
+
+// PieceAt returns the piece on sq, which must be on the board.
+func (b Board) PieceAt(sq Square) pieces.Piece {
+	return decodePiece(b.mailbox[sq])
+}
+
+// Squares returns the piece on each square, indexed by Square.
+func (b Board) Squares() [64]pieces.Piece {
+	var squares [64]pieces.Piece
+	for sq := A1; sq <= H8; sq++ {
+		squares[sq] = b.PieceAt(sq)
+	}
+	return squares
+}
+
+// Pieces returns the squares of team's pieces of type pt.
+func (b *Board) Pieces(pt pieces.PieceType, team pieces.Team) Bitboard {
+	return b.pieceBB[team][pt]
+}
+
+// TeamPieces returns the squares of all of team's pieces.
+func (b *Board) TeamPieces(team pieces.Team) Bitboard {
+	return b.teamBB[team]
+}
+
+// Occupied returns every occupied square.
+func (b *Board) Occupied() Bitboard {
+	return b.teamBB[0] | b.teamBB[1]
+}
+
+// boardJSON is the JSON form of a Board: the pieces square by square,
+// followed by the rest of the state.
+type boardJSON struct {
+	Squares        [64]pieces.Piece `json:"Squares"`
+	SideToMove     pieces.Team      `json:"side_to_move"`
+	HalfmoveClock  int              `json:"halfmove_clock"`
+	FullmoveNumber int              `json:"fullmove_number"`
+	Castling       CastlingRights   `json:"castling"`
+	EnPassant      Square           `json:"en_passant"`
+}
+
+// MarshalJSON writes the board with its pieces as an array of 64 squares.
+func (b Board) MarshalJSON() ([]byte, error) {
+	return json.Marshal(boardJSON{
+		Squares:        b.Squares(),
+		SideToMove:     b.SideToMove,
+		HalfmoveClock:  b.HalfmoveClock,
+		FullmoveNumber: b.FullmoveNumber,
+		Castling:       b.Castling,
+		EnPassant:      b.EnPassant,
+	})
+}
+
+// UnmarshalJSON reads a board written by MarshalJSON and computes its
+// Zobrist key, which is not part of the JSON form.
+func (b *Board) UnmarshalJSON(data []byte) error {
+	var v boardJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*b = Board{
+		SideToMove:     v.SideToMove,
+		HalfmoveClock:  v.HalfmoveClock,
+		FullmoveNumber: v.FullmoveNumber,
+		Castling:       v.Castling,
+		EnPassant:      v.EnPassant,
+	}
+	for sq, p := range v.Squares {
+		if p.Type < pieces.Empty && (p.Team == pieces.White || p.Team == pieces.Black) {
+			b.setPiece(Square(sq), p)
+		}
+	}
+	b.ResetHash()
+	return nil
+}
+
+// setPiece puts piece on sq, replacing whatever stood there. An empty
+// piece clears the square.
+func (b *Board) setPiece(sq Square, piece pieces.Piece) {
+	b.removePiece(sq)
+	if piece.Type != pieces.Empty {
+		b.addPiece(sq, piece.Type, piece.Team)
+	}
+}
+
+// addPiece puts a piece on the empty square sq.
+func (b *Board) addPiece(sq Square, pt pieces.PieceType, team pieces.Team) {
+	bb := squareBB(sq)
+	b.pieceBB[team][pt] |= bb
+	b.teamBB[team] |= bb
+	b.mailbox[sq] = pieceCode(pt, team)
+	b.hash ^= pieceKey(sq, pt, team)
+}
+
+// removePiece empties sq.
+func (b *Board) removePiece(sq Square) {
+	code := b.mailbox[sq]
+	if code == 0 {
+		return
+	}
+	p := decodePiece(code)
+	bb := squareBB(sq)
+	b.pieceBB[p.Team][p.Type] &^= bb
+	b.teamBB[p.Team] &^= bb
+	b.mailbox[sq] = 0
+	b.hash ^= pieceKey(sq, p.Type, p.Team)
+}
+
+// shiftPiece moves the piece on from to the empty square to.
+func (b *Board) shiftPiece(from, to Square) {
+	p := decodePiece(b.mailbox[from])
+	bb := squareBB(from) | squareBB(to)
+	b.pieceBB[p.Team][p.Type] ^= bb
+	b.teamBB[p.Team] ^= bb
+	b.mailbox[to] = b.mailbox[from]
+	b.mailbox[from] = 0
+	b.hash ^= pieceKey(from, p.Type, p.Team) ^ pieceKey(to, p.Type, p.Team)
 }
 
-type Coordinate struct {
-	X int
-	Y int
+// MovePiece plays m, reading only its From, To and Promotion fields, and
+// returns the new board together with the move as applied, with the moved
+// and captured pieces and the flags filled in. A pawn moving to the last
+// rank must name its promotion piece.
+//
+// An illegal move returns an *IllegalMoveError. If the side to move has no
+// legal moves at all its reason is ErrGameOver.
+func (b Board) MovePiece(m Move) (Board, Move, error) {
+	next, applied, err := b.movePiece(m)
+	if err == nil {
+		return next, applied, nil
+	}
+	if !b.hasLegalMove() {
+		return b, m, &IllegalMoveError{Move: m, Reason: ErrGameOver}
+	}
+	var illegalErr *IllegalMoveError
+	if errors.As(err, &illegalErr) {
+		illegalErr.Move = m
+	}
+	return b, m, err
 }
 
-func (b Board) MovePiece(start, end Coordinate) (Board, error) {
+// movePiece plays m if it is one of the moves LegalMoves generates, so the
+// two can never disagree about what is legal. Otherwise whyIllegal
+// explains why m is illegal.
+func (b Board) movePiece(m Move) (Board, Move, error) {
+	var buf [maxMoves]Move
+	for _, legal := range b.AppendLegalMoves(buf[:0]) {
+		if legal.From == m.From && legal.To == m.To && legal.Promotion == m.Promotion {
+			next := b
+			next.apply(legal)
+			if next.InCheck() {
+				legal.Flags |= FlagCheck
+			}
+			return next, legal, nil
+		}
+	}
+	if err := b.whyIllegal(m); err != nil {
+		return b, m, err
+	}
+	// the rules allow m, yet the generator does not
+	return b, m, illegal(ErrInvalidMovement, "%s is not a legal move", m.UCI())
+}
+
+// whyIllegal checks m against each of the rules in turn and returns an
+// *IllegalMoveError for the first one it breaks, or nil if it breaks
+// none.
+func (b Board) whyIllegal(m Move) error {
+	start, end, promotion := m.From, m.To, m.Promotion
 	piece, err := b.getPiece(start)
-	b.MoveCount += 1
 	if err != nil {
-		return b, err
+		return err
+	}
+	if piece.Type == pieces.Empty {
+		return &IllegalMoveError{Reason: ErrEmptySquare}
+	}
+	if piece.Team != b.SideToMove {
+		return &IllegalMoveError{Reason: &WrongTurnError{Team: piece.Team, SideToMove: b.SideToMove}}
+	}
+	target, err := b.getPiece(end)
+	if err != nil {
+		return err
+	}
+	if start == end {
+		return illegal(ErrInvalidMovement, "piece must move to a different square")
+	}
+	if target.Type != pieces.Empty && target.Team == piece.Team {
+		return &IllegalMoveError{Reason: ErrOwnPieceCapture}
+	}
+	if piece.Type != pieces.Pawn && promotion != pieces.Empty {
+		return illegal(ErrInvalidPromotion, "only pawns can be promoted")
+	}
+
+	applied := Move{From: start, To: end, Piece: piece, Captured: target, Promotion: promotion}
+	if target.Type != pieces.Empty {
+		applied.Flags |= FlagCapture
 	}
 	switch piece.Type {
 	case pieces.Pawn:
-		b, err = b.movePawn(start, end, &piece)
-		if err != nil {
-			return b, err
-		}
-		return b, nil
-
+		err = b.checkPawn(&applied)
 	case pieces.Rook:
-		b, err = b.moveRook(start, end, piece)
-		return b, nil
+		err = b.checkRook(start, end)
 	case pieces.Knight:
-		b, err = b.moveKnight(start, end, piece)
-		return b, nil
+		err = b.checkKnight(start, end)
 	case pieces.Bishop:
-		b, err = b.moveBishop(start, end, piece)
-		return b, nil
+		err = b.checkBishop(start, end)
 	case pieces.Queen:
-		b, err = b.moveQueen(start, end, piece)
-		return b, nil
+		err = b.checkQueen(start, end)
 	case pieces.King:
-		b, err = b.moveKing(start, end, piece)
-		return b, nil
+		err = b.checkKing(&applied)
+	default:
+		err = illegal(ErrInvalidMovement, "unknown piece type %d", piece.Type)
+	}
+	if err != nil {
+		return err
 	}
-	return b, nil
 
+	moved := b
+	moved.apply(applied)
+	if moved.inCheck(piece.Team) {
+		return &IllegalMoveError{Reason: ErrKingInCheck}
+	}
+	return nil
 }
 
-func (b Board) movePawn(start, end Coordinate, piece *pieces.Piece) (Board, error) {
-	//validate that pawn can move
-	if piece.MoveCount == 0 {
-		//pawn can move up to 2 squares vertically
-		if piece.Team == pieces.White {
-			if start.X < end.X {
-				return b, errors.New("white pawn must move forward")
-			}
+// apply plays a move whose piece, captured piece and flags are filled in,
+// without checking that it is legal.
+func (b *Board) apply(m Move) {
+	mover := m.Piece.Team
+	b.hash ^= b.enPassantKey()
+	b.EnPassant = NoSquare
 
-		} else if piece.Team == pieces.Black {
-			if start.X > end.X {
-				return b, errors.New("black pawn must move forward")
-			}
+	switch {
+	case m.Flags.Has(FlagEnPassant):
+		b.removePiece(NewSquare(m.To.File(), m.From.Rank()))
+	case m.Flags.Has(FlagCapture):
+		b.removePiece(m.To)
+	}
+	if m.Flags.Has(FlagPromotion) {
+		b.removePiece(m.From)
+		b.addPiece(m.To, m.Promotion, mover)
+	} else {
+		b.shiftPiece(m.From, m.To)
+	}
+
+	rank := homeRank(mover)
+	switch {
+	case m.Flags.Has(FlagKingsideCastle):
+		b.shiftPiece(NewSquare(7, rank), NewSquare(5, rank))
+	case m.Flags.Has(FlagQueensideCastle):
+		b.shiftPiece(NewSquare(0, rank), NewSquare(3, rank))
+	case m.Flags.Has(FlagDoublePush):
+		b.EnPassant = Square((int(m.From) + int(m.To)) / 2)
+	}
 
+	// moving a king or rook, or capturing a rook, gives up castling on that side
+	if lost := b.Castling & (castlingRightsAt(m.From) | castlingRightsAt(m.To)); lost != 0 {
+		b.hash ^= castlingKey(b.Castling) ^ castlingKey(b.Castling&^lost)
+		b.Castling &^= lost
+	}
+
+	if m.Piece.Type == pieces.Pawn || m.Flags.Has(FlagCapture) {
+		b.HalfmoveClock = 0
+	} else {
+		b.HalfmoveClock++
+	}
+	if mover == pieces.Black {
+		b.FullmoveNumber++
+	}
+	b.SideToMove = mover.Opponent()
+	b.hash ^= polyglotRandom[780] ^ b.enPassantKey()
+}
+
+// checkPawn checks a pawn move and fills in its en passant, double push
+// and promotion details.
+func (b Board) checkPawn(m *Move) error {
+	start, end, piece := m.From, m.To, m.Piece
+	dir, startRank, lastRank := pawnRanks(piece.Team)
+	df, dr := end.File()-start.File(), end.Rank()-start.Rank()
+	target := b.PieceAt(end)
+
+	switch {
+	case df == 0 && dr == dir:
+		if target.Type != pieces.Empty {
+			return illegal(ErrInvalidMovement, "pawn cannot capture straight ahead")
+		}
+	case df == 0 && dr == 2*dir:
+		if start.Rank() != startRank {
+			return illegal(ErrInvalidMovement, "pawn can only move two squares from its starting rank")
+		}
+		if target.Type != pieces.Empty {
+			return illegal(ErrInvalidMovement, "pawn cannot capture straight ahead")
+		}
+		if err := b.checkPath(start, end); err != nil {
+			return err
 		}
+		m.Flags |= FlagDoublePush
+	case abs(df) == 1 && dr == dir:
+		if target.Type == pieces.Empty {
+			if b.EnPassant != end {
+				return illegal(ErrInvalidMovement, "pawn can only move diagonally when capturing")
+			}
+			// en passant: the captured pawn sits beside the start square
+			m.Captured = b.PieceAt(NewSquare(end.File(), start.Rank()))
+			m.Flags |= FlagCapture | FlagEnPassant
+		}
+	default:
+		return illegal(ErrInvalidMovement, "%s pawn must move forward one square, two from its starting rank, or capture diagonally", piece.Team)
+	}
 
+	if end.Rank() == lastRank {
+		switch m.Promotion {
+		case pieces.Knight, pieces.Bishop, pieces.Rook, pieces.Queen:
+			m.Flags |= FlagPromotion
+		case pieces.Empty:
+			return illegal(ErrInvalidPromotion, "pawn reaching the last rank must be promoted")
+		default:
+			return illegal(ErrInvalidPromotion, "pawn cannot be promoted to a %s", m.Promotion)
+		}
+	} else if m.Promotion != pieces.Empty {
+		return illegal(ErrInvalidPromotion, "pawn can only be promoted on the last rank")
 	}
+	return nil
+}
 
-	//passed validation move the pawn
-	piece.MoveCount += 1
-	fmt.Println("adding 1 to Pawn Move count")
-	b.Squares[start.X][start.Y] = pieces.Piece{Type: pieces.Empty, Team: pieces.Neutral}
-	b.Squares[end.X][end.Y] = *piece
-	return b, nil
+// pawnRanks returns the rank direction a pawn of team moves in, the rank it
+// starts on and the rank it promotes on.
+func pawnRanks(team pieces.Team) (dir, startRank, lastRank int) {
+	if team == pieces.White {
+		return 1, 1, 7
+	}
+	return -1, 6, 0
 }
 
-func (b Board) moveRook(start, end Coordinate, piece pieces.Piece) (Board, error) {
-	b.Squares[start.X][start.Y] = pieces.Piece{Type: pieces.Empty, Team: pieces.Neutral}
-	b.Squares[end.X][end.Y] = piece
-	return b, nil
+func (b Board) checkRook(start, end Square) error {
+	if !isStraight(start, end) {
+		return illegal(ErrInvalidMovement, "rook must move along a rank or file")
+	}
+	return b.checkPath(start, end)
 }
 
-func (b Board) moveKnight(start, end Coordinate, piece pieces.Piece) (Board, error) {
-	b.Squares[start.X][start.Y] = pieces.Piece{Type: pieces.Empty, Team: pieces.Neutral}
-	b.Squares[end.X][end.Y] = piece
-	return b, nil
+func (b Board) checkKnight(start, end Square) error {
+	df, dr := abs(end.File()-start.File()), abs(end.Rank()-start.Rank())
+	if !(df == 1 && dr == 2) && !(df == 2 && dr == 1) {
+		return illegal(ErrInvalidMovement, "knight must move in an L shape")
+	}
+	return nil
 }
 
-func (b Board) moveBishop(start, end Coordinate, piece pieces.Piece) (Board, error) {
-	b.Squares[start.X][start.Y] = pieces.Piece{Type: pieces.Empty, Team: pieces.Neutral}
-	b.Squares[end.X][end.Y] = piece
-	return b, nil
+func (b Board) checkBishop(start, end Square) error {
+	if !isDiagonal(start, end) {
+		return illegal(ErrInvalidMovement, "bishop must move diagonally")
+	}
+	return b.checkPath(start, end)
 }
 
-func (b Board) moveQueen(start, end Coordinate, piece pieces.Piece) (Board, error) {
-	b.Squares[start.X][start.Y] = pieces.Piece{Type: pieces.Empty, Team: pieces.Neutral}
-	b.Squares[end.X][end.Y] = piece
-	return b, nil
+func (b Board) checkQueen(start, end Square) error {
+	if !isStraight(start, end) && !isDiagonal(start, end) {
+		return illegal(ErrInvalidMovement, "queen must move along a rank, file or diagonal")
+	}
+	return b.checkPath(start, end)
 }
 
-func (b Board) moveKing(start, end Coordinate, piece pieces.Piece) (Board, error) {
-	b.Squares[start.X][start.Y] = pieces.Piece{Type: pieces.Empty, Team: pieces.Neutral}
-	b.Squares[end.X][end.Y] = piece
-	return b, nil
+// checkKing checks a king move, treating a move of two squares along the
+// home rank as castling.
+func (b Board) checkKing(m *Move) error {
+	df, dr := m.To.File()-m.From.File(), m.To.Rank()-m.From.Rank()
+	if dr == 0 && abs(df) == 2 {
+		return b.checkCastle(m)
+	}
+	if abs(df) > 1 || abs(dr) > 1 {
+		return illegal(ErrInvalidMovement, "king can only move one square")
+	}
+	return nil
 }
 
-func (b Board) getPiece(coord Coordinate) (pieces.Piece, error) {
-	if coord.X > 7 || coord.Y > 7 || coord.X < 0 || coord.Y < 0 {
+func (b Board) getPiece(sq Square) (pieces.Piece, error) {
+	if !sq.Valid() {
 		noPiece := pieces.Piece{
 			Type: 99,
 			Team: 99,
 		}
 
-		return noPiece, errors.New("coordinate must be within bounds of the board")
+		return noPiece, illegal(ErrOutOfBounds, "square must be on the board")
+	}
+	return b.PieceAt(sq), nil
+
+}
+
+// checkPath returns an error if any square strictly between start and end
+// is occupied. start and end must share a rank, file or diagonal.
+func (b Board) checkPath(start, end Square) error {
+	df, dr := sign(end.File()-start.File()), sign(end.Rank()-start.Rank())
+	for sq := start.Offset(df, dr); sq != end; sq = sq.Offset(df, dr) {
+		if b.mailbox[sq] != 0 {
+			return illegal(ErrPathBlocked, "path is blocked at %s", sq)
+		}
+	}
+	return nil
+}
+
+func isStraight(start, end Square) bool {
+	return start.File() == end.File() || start.Rank() == end.Rank()
+}
+
+func isDiagonal(start, end Square) bool {
+	return abs(end.File()-start.File()) == abs(end.Rank()-start.Rank())
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
 	}
-	return b.Squares[coord.X][coord.Y], nil
+	return n
+}
 
+func sign(n int) int {
+	switch {
+	case n > 0:
+		return 1
+	case n < 0:
+		return -1
+	}
+	return 0
 }
 
 func CreateDefaultBoard() Board {
 	var board Board
-	board.MoveCount = 1
+	board.SideToMove = pieces.White
+	board.FullmoveNumber = 1
+	board.Castling = AllCastlingRights
+	board.EnPassant = NoSquare
 
 	// Pawns
 	for file := 0; file < 8; file++ {
-		board.Squares[6][file] = pieces.Piece{Type: pieces.Pawn, Team: pieces.White}
-		board.Squares[5][file] = pieces.Piece{Type: pieces.Empty, Team: pieces.Neutral}
-		board.Squares[4][file] = pieces.Piece{Type: pieces.Empty, Team: pieces.Neutral}
-		board.Squares[3][file] = pieces.Piece{Type: pieces.Empty, Team: pieces.Neutral}
-		board.Squares[2][file] = pieces.Piece{Type: pieces.Empty, Team: pieces.Neutral}
-		board.Squares[1][file] = pieces.Piece{Type: pieces.Pawn, Team: pieces.Black}
+		board.setPiece(NewSquare(file, 1), pieces.Piece{Type: pieces.Pawn, Team: pieces.White})
+		board.setPiece(NewSquare(file, 6), pieces.Piece{Type: pieces.Pawn, Team: pieces.Black})
 	}
 
 	// Back ranks
@@ -140,9 +493,10 @@ func CreateDefaultBoard() Board {
 	}
 
 	for file, pt := range backRank {
-		board.Squares[7][file] = pieces.Piece{Type: pt, Team: pieces.White}
-		board.Squares[0][file] = pieces.Piece{Type: pt, Team: pieces.Black}
+		board.setPiece(NewSquare(file, 0), pieces.Piece{Type: pt, Team: pieces.White})
+		board.setPiece(NewSquare(file, 7), pieces.Piece{Type: pt, Team: pieces.Black})
 	}
+	board.ResetHash()
 
 	return board
 }
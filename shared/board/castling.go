@@ -0,0 +1,105 @@
+package board
+
+import (
+	"github.com/tygermarshall/blunderbuss/shared/pieces"
+)
+
+// CastlingRights is a set of flags recording which castling moves are still
+// available. A right is lost for good once the king or the matching rook
+// moves, or the rook is captured.
+type CastlingRights uint8
+
+const (
+	WhiteKingside CastlingRights = 1 << iota
+	WhiteQueenside
+	BlackKingside
+	BlackQueenside
+
+	NoCastlingRights  CastlingRights = 0
+	AllCastlingRights                = WhiteKingside | WhiteQueenside | BlackKingside | BlackQueenside
+)
+
+// Has reports whether every right in r is still available.
+func (c CastlingRights) Has(r CastlingRights) bool {
+	return c&r == r
+}
+
+// homeRank returns the rank a team's king and rooks start on.
+func homeRank(team pieces.Team) int {
+	if team == pieces.White {
+		return 0
+	}
+	return 7
+}
+
+// castlingRightsAt returns the rights that are lost when a piece moves from
+// or to sq.
+func castlingRightsAt(sq Square) CastlingRights {
+	switch sq {
+	case E1:
+		return WhiteKingside | WhiteQueenside
+	case H1:
+		return WhiteKingside
+	case A1:
+		return WhiteQueenside
+	case E8:
+		return BlackKingside | BlackQueenside
+	case H8:
+		return BlackKingside
+	case A8:
+		return BlackQueenside
+	}
+	return NoCastlingRights
+}
+
+// checkCastle checks a king move of two squares along its home rank as
+// castling and sets the castling flag. The king may not castle out of,
+// through or into check.
+func (b Board) checkCastle(m *Move) error {
+	start, end, king := m.From, m.To, m.Piece
+	rank := homeRank(king.Team)
+	if start != NewSquare(4, rank) {
+		return illegal(ErrCastlingForbidden, "king can only castle from its starting square")
+	}
+
+	kingside := end > start
+	var right CastlingRights
+	var rookFrom, rookTo Square
+	if kingside {
+		right = WhiteKingside
+		rookFrom, rookTo = NewSquare(7, rank), NewSquare(5, rank)
+	} else {
+		right = WhiteQueenside
+		rookFrom, rookTo = NewSquare(0, rank), NewSquare(3, rank)
+	}
+	if king.Team == pieces.Black {
+		right <<= 2
+	}
+	if !b.Castling.Has(right) {
+		return illegal(ErrCastlingForbidden, "castling rights on that side have been lost")
+	}
+
+	if !b.hasPiece(rookFrom, pieces.Rook, king.Team) {
+		return illegal(ErrCastlingForbidden, "no rook to castle with")
+	}
+	if err := b.checkPath(start, rookFrom); err != nil {
+		return err
+	}
+
+	enemy := king.Team.Opponent()
+	if b.isAttacked(start, enemy) {
+		return illegal(ErrCastlingForbidden, "cannot castle out of check")
+	}
+	if b.isAttacked(rookTo, enemy) {
+		return illegal(ErrCastlingForbidden, "cannot castle through check")
+	}
+	if b.isAttacked(end, enemy) {
+		return illegal(ErrCastlingForbidden, "cannot castle into check")
+	}
+	if kingside {
+		m.Flags |= FlagKingsideCastle
+	} else {
+		m.Flags |= FlagQueensideCastle
+	}
+	return nil
+}
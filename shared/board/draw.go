@@ -0,0 +1,67 @@
+package board
+
+import (
+	"github.com/tygermarshall/blunderbuss/shared/pieces"
+)
+
+// CanClaimFiftyMoveDraw reports whether each side has made fifty moves
+// without a capture or a pawn move, so the side to move may claim a draw.
+func (b Board) CanClaimFiftyMoveDraw() bool {
+	return b.HalfmoveClock >= 100
+}
+
+// IsSeventyFiveMoveDraw reports whether each side has made seventy-five
+// moves without a capture or a pawn move, which draws the game unless the
+// last move gave checkmate.
+func (b Board) IsSeventyFiveMoveDraw() bool {
+	return b.HalfmoveClock >= 150
+}
+
+// IsInsufficientMaterial reports whether neither side can checkmate by any
+// series of legal moves: king against king, king and a single knight or
+// bishop against king, or kings and any number of bishops all standing on
+// squares of the same colour.
+func (b Board) IsInsufficientMaterial() bool {
+	both := func(pt pieces.PieceType) Bitboard {
+		return b.pieceBB[pieces.White][pt] | b.pieceBB[pieces.Black][pt]
+	}
+	knights, bishops := both(pieces.Knight), both(pieces.Bishop)
+	if b.Occupied()&^(both(pieces.King)|knights|bishops) != 0 {
+		return false
+	}
+	switch {
+	case (knights | bishops).Count() <= 1:
+		return true
+	case knights == 0:
+		return bishops&lightSquares == 0 || bishops&^lightSquares == 0
+	}
+	return false
+}
+
+// PositionKey identifies the position for the repetition rules: two boards
+// have the same key when the same pieces stand on the same squares, the
+// same side is to move, and the same castling and en passant captures are
+// possible. It is Hash, except that the en passant file only counts when a
+// legal capture onto it exists.
+func (b Board) PositionKey() uint64 {
+	if key := b.enPassantKey(); key != 0 && !b.canCaptureEnPassant() {
+		return b.hash ^ key
+	}
+	return b.hash
+}
+
+// canCaptureEnPassant reports whether the side to move has a legal en
+// passant capture.
+func (b Board) canCaptureEnPassant() bool {
+	dir, _, _ := pawnRanks(b.SideToMove)
+	for _, df := range [2]int{-1, 1} {
+		from := b.EnPassant.Offset(df, -dir)
+		if !from.Valid() || !b.hasPiece(from, pieces.Pawn, b.SideToMove) {
+			continue
+		}
+		if _, _, err := b.movePiece(NewMove(from, b.EnPassant)); err == nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,349 @@
+package board
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ErrInvalidEPD is wrapped by every error ParseEPD returns.
+var ErrInvalidEPD = errors.New("invalid epd")
+
+func epdError(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrInvalidEPD, fmt.Sprintf(format, args...))
+}
+
+// Operation is one EPD opcode with its operands, e.g. bm Nf3 Qd2 or
+// id "WAC.001". Quoted string operands are kept without their quotes.
+type Operation struct {
+	Opcode   string
+	Operands []string
+}
+
+// EPD is a position in Extended Position Description: the first four FEN
+// fields followed by operations, as used by engine test suites such as
+// WAC, STS or Bratko-Kopec.
+type EPD struct {
+	// Board is the position. Its move clocks come from the hmvc and fmvn
+	// operations or the FEN clock fields, or are 0 and 1 when those are
+	// missing.
+	Board      Board
+	Operations []Operation
+}
+
+// ParseEPD reads one EPD record. The position is checked as ParseFEN
+// checks it, and the operands of the opcodes EPD gives a type to (bm, am,
+// acd, ce, hmvc and fmvn) must be valid for it.
+//
+// Some files write the halfmove clock and fullmove number after the
+// fourth field, as in FEN. They are accepted, since an opcode cannot
+// start with a digit, but hmvc and fmvn operations win over them.
+func ParseEPD(s string) (EPD, error) {
+	fields := strings.Fields(s)
+	if len(fields) < 4 {
+		return EPD{}, epdError("expected at least 4 fields, got %d", len(fields))
+	}
+	b, err := parsePosition(fields[:4])
+	if err != nil {
+		return EPD{}, fmt.Errorf("%w: %w", ErrInvalidEPD, err)
+	}
+	b.FullmoveNumber = 1
+	skip := 4
+	if len(fields) >= 6 && isNumber(fields[4]) && isNumber(fields[5]) {
+		b.HalfmoveClock, _ = strconv.Atoi(fields[4])
+		b.FullmoveNumber, _ = strconv.Atoi(fields[5])
+		if b.FullmoveNumber < 1 {
+			return EPD{}, epdError("fullmove number %q is not a positive number", fields[5])
+		}
+		skip = 6
+	}
+
+	// the operations are whatever follows the position fields
+	rest := s
+	for i := 0; i < skip; i++ {
+		rest = strings.TrimLeftFunc(rest, unicode.IsSpace)
+		rest = rest[strings.IndexFunc(rest+" ", unicode.IsSpace):]
+	}
+	ops, err := parseOperations(rest)
+	if err != nil {
+		return EPD{}, err
+	}
+	e := EPD{Board: b, Operations: ops}
+
+	if n, ok, err := e.intOp("hmvc"); err != nil || (ok && n < 0) {
+		return EPD{}, epdError("hmvc must be a non-negative number")
+	} else if ok {
+		e.Board.HalfmoveClock = n
+	}
+	if n, ok, err := e.intOp("fmvn"); err != nil || (ok && n < 1) {
+		return EPD{}, epdError("fmvn must be a positive number")
+	} else if ok {
+		e.Board.FullmoveNumber = n
+	}
+	for _, opcode := range []string{"acd", "ce"} {
+		if _, _, err := e.intOp(opcode); err != nil {
+			return EPD{}, err
+		}
+	}
+	if _, err := e.BestMoves(); err != nil {
+		return EPD{}, err
+	}
+	if _, err := e.AvoidMoves(); err != nil {
+		return EPD{}, err
+	}
+	return e, nil
+}
+
+// parseOperations splits s into operations, each an opcode followed by
+// operands and ended by a semicolon.
+func parseOperations(s string) ([]Operation, error) {
+	var ops []Operation
+	var words []string
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			i++
+		case c == ';':
+			if len(words) == 0 {
+				return nil, epdError("empty operation")
+			}
+			if !isOpcode(words[0]) {
+				return nil, epdError("invalid opcode %q", words[0])
+			}
+			ops = append(ops, Operation{Opcode: words[0], Operands: words[1:]})
+			words = nil
+			i++
+		case c == '"':
+			end := strings.IndexByte(s[i+1:], '"')
+			if end < 0 {
+				return nil, epdError("unterminated string operand")
+			}
+			words = append(words, s[i+1:i+1+end])
+			i += end + 2
+		default:
+			end := strings.IndexAny(s[i:], " \t\r\n;\"")
+			if end < 0 {
+				end = len(s) - i
+			}
+			words = append(words, s[i:i+end])
+			i += end
+		}
+	}
+	if len(words) > 0 {
+		return nil, epdError("operation %q is not ended by a semicolon", words[0])
+	}
+	return ops, nil
+}
+
+// isOpcode reports whether s is a valid opcode: a letter followed by up to
+// 14 letters, digits or underscores.
+func isOpcode(s string) bool {
+	if len(s) == 0 || len(s) > 15 || !isLetter(s[0]) {
+		return false
+	}
+	for i := 1; i < len(s); i++ {
+		if c := s[i]; !isLetter(c) && !(c >= '0' && c <= '9') && c != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+// isNumber reports whether s is a non-empty run of decimal digits.
+func isNumber(s string) bool {
+	if s == "" || len(s) > 9 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// String writes the record in EPD: the position fields followed by each
+// operation and its semicolon.
+func (e EPD) String() string {
+	var sb strings.Builder
+	sb.WriteString(e.Board.placementFEN())
+	sb.WriteByte(' ')
+	sb.WriteString(e.Board.stateFEN())
+	for _, op := range e.Operations {
+		sb.WriteByte(' ')
+		sb.WriteString(op.Opcode)
+		for _, operand := range op.Operands {
+			sb.WriteByte(' ')
+			if isStringOpcode(op.Opcode) || operand == "" || strings.ContainsAny(operand, " \t;\"") {
+				sb.WriteString(`"` + operand + `"`)
+			} else {
+				sb.WriteString(operand)
+			}
+		}
+		sb.WriteByte(';')
+	}
+	return sb.String()
+}
+
+// isStringOpcode reports whether the opcode's operands are always quoted
+// strings: id and the comments c0 to c9.
+func isStringOpcode(opcode string) bool {
+	return opcode == "id" || len(opcode) == 2 && opcode[0] == 'c' && opcode[1] >= '0' && opcode[1] <= '9'
+}
+
+// Op returns the operands of the first operation with the given opcode.
+func (e EPD) Op(opcode string) ([]string, bool) {
+	for _, op := range e.Operations {
+		if op.Opcode == opcode {
+			return op.Operands, true
+		}
+	}
+	return nil, false
+}
+
+// SetOp sets the operands of the given opcode, replacing an existing
+// operation in place or adding one at the end.
+func (e *EPD) SetOp(opcode string, operands ...string) {
+	for i, op := range e.Operations {
+		if op.Opcode == opcode {
+			e.Operations[i].Operands = operands
+			return
+		}
+	}
+	e.Operations = append(e.Operations, Operation{Opcode: opcode, Operands: operands})
+}
+
+// DeleteOp removes every operation with the given opcode.
+func (e *EPD) DeleteOp(opcode string) {
+	ops := e.Operations[:0]
+	for _, op := range e.Operations {
+		if op.Opcode != opcode {
+			ops = append(ops, op)
+		}
+	}
+	e.Operations = ops
+}
+
+// stringOp returns the single operand of a string opcode.
+func (e EPD) stringOp(opcode string) string {
+	operands, _ := e.Op(opcode)
+	return strings.Join(operands, " ")
+}
+
+// intOp returns the single integer operand of opcode.
+func (e EPD) intOp(opcode string) (int, bool, error) {
+	operands, ok := e.Op(opcode)
+	if !ok {
+		return 0, false, nil
+	}
+	if len(operands) != 1 {
+		return 0, false, epdError("%s takes one operand, got %d", opcode, len(operands))
+	}
+	n, err := strconv.Atoi(operands[0])
+	if err != nil {
+		return 0, false, epdError("%s operand %q is not a number", opcode, operands[0])
+	}
+	return n, true, nil
+}
+
+// moveOp returns the SAN operands of opcode as legal moves.
+func (e EPD) moveOp(opcode string) ([]Move, error) {
+	operands, _ := e.Op(opcode)
+	moves := make([]Move, 0, len(operands))
+	for _, san := range operands {
+		m, err := e.Board.ParseSAN(san)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %w", ErrInvalidEPD, opcode, err)
+		}
+		moves = append(moves, m)
+	}
+	return moves, nil
+}
+
+// setMoveOp sets opcode to moves written in SAN.
+func (e *EPD) setMoveOp(opcode string, moves []Move) error {
+	operands := make([]string, len(moves))
+	for i, m := range moves {
+		san, err := e.Board.SAN(m)
+		if err != nil {
+			return err
+		}
+		operands[i] = san
+	}
+	e.SetOp(opcode, operands...)
+	return nil
+}
+
+// BestMoves returns the moves of the bm opcode, any of which solves the
+// position.
+func (e EPD) BestMoves() ([]Move, error) {
+	return e.moveOp("bm")
+}
+
+// SetBestMoves sets the bm opcode. Every move must be legal.
+func (e *EPD) SetBestMoves(moves ...Move) error {
+	return e.setMoveOp("bm", moves)
+}
+
+// AvoidMoves returns the moves of the am opcode, none of which should be
+// played.
+func (e EPD) AvoidMoves() ([]Move, error) {
+	return e.moveOp("am")
+}
+
+// SetAvoidMoves sets the am opcode. Every move must be legal.
+func (e *EPD) SetAvoidMoves(moves ...Move) error {
+	return e.setMoveOp("am", moves)
+}
+
+// ID returns the position's name from the id opcode, or "" if it has
+// none.
+func (e EPD) ID() string {
+	return e.stringOp("id")
+}
+
+// SetID sets the id opcode.
+func (e *EPD) SetID(id string) {
+	e.SetOp("id", id)
+}
+
+// Comment returns comment n, from 0 to 9, from the c0 to c9 opcodes.
+func (e EPD) Comment(n int) string {
+	return e.stringOp("c" + strconv.Itoa(n))
+}
+
+// SetComment sets comment n, from 0 to 9.
+func (e *EPD) SetComment(n int, comment string) {
+	e.SetOp("c"+strconv.Itoa(n), comment)
+}
+
+// AnalysisDepth returns the depth in plies, from the acd opcode, that the
+// position was analysed to.
+func (e EPD) AnalysisDepth() (int, bool) {
+	n, ok, err := e.intOp("acd")
+	return n, ok && err == nil
+}
+
+// SetAnalysisDepth sets the acd opcode.
+func (e *EPD) SetAnalysisDepth(plies int) {
+	e.SetOp("acd", strconv.Itoa(plies))
+}
+
+// CentipawnEval returns the evaluation, from the ce opcode, in centipawns
+// from the point of view of the side to move.
+func (e EPD) CentipawnEval() (int, bool) {
+	n, ok, err := e.intOp("ce")
+	return n, ok && err == nil
+}
+
+// SetCentipawnEval sets the ce opcode.
+func (e *EPD) SetCentipawnEval(cp int) {
+	e.SetOp("ce", strconv.Itoa(cp))
+}
@@ -0,0 +1,141 @@
+package board
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// uciMoves returns moves in UCI.
+func uciMoves(moves []Move) []string {
+	out := make([]string, len(moves))
+	for i, m := range moves {
+		out[i] = m.UCI()
+	}
+	return out
+}
+
+func TestParseEPD(t *testing.T) {
+	const wac2 = `8/7p/5k2/5p2/p1p2P2/Pr1pPK2/1P1R3P/8 b - - bm Rxb2; id "WAC.002"; c0 "a comment; with a semicolon";`
+	e, err := ParseEPD(wac2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := e.Board.FEN(), "8/7p/5k2/5p2/p1p2P2/Pr1pPK2/1P1R3P/8 b - - 0 1"; got != want {
+		t.Errorf("board %s, want %s", got, want)
+	}
+	if bm, err := e.BestMoves(); err != nil || !reflect.DeepEqual(uciMoves(bm), []string{"b3b2"}) {
+		t.Errorf("bm %v, %v", uciMoves(bm), err)
+	}
+	if e.ID() != "WAC.002" {
+		t.Errorf("id %q", e.ID())
+	}
+	if e.Comment(0) != "a comment; with a semicolon" {
+		t.Errorf("c0 %q", e.Comment(0))
+	}
+	if got := e.String(); got != wac2 {
+		t.Errorf("written as\n%s\nwant\n%s", got, wac2)
+	}
+
+	e, err = ParseEPD(`r1bqkbnr/pppp1ppp/2n5/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R w KQkq - bm Bb5 Bc4; am Qe2 Ke2; acd 12; ce -15; hmvc 2; fmvn 3;`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bm, _ := e.BestMoves(); !reflect.DeepEqual(uciMoves(bm), []string{"f1b5", "f1c4"}) {
+		t.Errorf("bm %v", uciMoves(bm))
+	}
+	if am, _ := e.AvoidMoves(); !reflect.DeepEqual(uciMoves(am), []string{"d1e2", "e1e2"}) {
+		t.Errorf("am %v", uciMoves(am))
+	}
+	if n, ok := e.AnalysisDepth(); n != 12 || !ok {
+		t.Errorf("acd %d, %v", n, ok)
+	}
+	if n, ok := e.CentipawnEval(); n != -15 || !ok {
+		t.Errorf("ce %d, %v", n, ok)
+	}
+	if e.Board.HalfmoveClock != 2 || e.Board.FullmoveNumber != 3 {
+		t.Errorf("clocks %d %d, want 2 3", e.Board.HalfmoveClock, e.Board.FullmoveNumber)
+	}
+}
+
+func TestParseEPDClockFields(t *testing.T) {
+	tests := []struct {
+		epd                string
+		halfmove, fullmove int
+		ops                int
+	}{
+		{"5k2/8/8/8/8/8/8/4K2R w K - 7 40", 7, 40, 0},
+		{"5k2/8/8/8/8/8/8/4K2R w K - 7 40 bm O-O+;", 7, 40, 1},
+		{"5k2/8/8/8/8/8/8/4K2R w K - 7 40 hmvc 9; fmvn 41;", 9, 41, 2},
+		{"5k2/8/8/8/8/8/8/4K2R w K - bm O-O+;", 0, 1, 1},
+	}
+	for _, tt := range tests {
+		e, err := ParseEPD(tt.epd)
+		if err != nil {
+			t.Errorf("%s: %v", tt.epd, err)
+			continue
+		}
+		if e.Board.HalfmoveClock != tt.halfmove || e.Board.FullmoveNumber != tt.fullmove || len(e.Operations) != tt.ops {
+			t.Errorf("%s: clocks %d %d with %d operations, want %d %d with %d",
+				tt.epd, e.Board.HalfmoveClock, e.Board.FullmoveNumber, len(e.Operations), tt.halfmove, tt.fullmove, tt.ops)
+		}
+	}
+}
+
+func TestParseEPDRejects(t *testing.T) {
+	tests := []struct {
+		name, epd string
+	}{
+		{"three fields", "4k3/8/8/8/8/8/8/4K3 w -"},
+		{"bad position", "4k3/8/8/8/8/8/8/4K3 w K - bm Kd1;"},
+		{"one clock field", "4k3/8/8/8/8/8/8/4K3 w - - 7 bm Kd1;"},
+		{"zero fullmove number", "4k3/8/8/8/8/8/8/4K3 w - - 0 0"},
+		{"no semicolon", "4k3/8/8/8/8/8/8/4K3 w - - bm Kd1"},
+		{"empty operation", "4k3/8/8/8/8/8/8/4K3 w - - ;"},
+		{"bad opcode", "4k3/8/8/8/8/8/8/4K3 w - - 1bm Kd1;"},
+		{"unterminated string", `4k3/8/8/8/8/8/8/4K3 w - - id "WAC.001;`},
+		{"illegal best move", "4k3/8/8/8/8/8/8/4K3 w - - bm Ke3;"},
+		{"illegal move to avoid", "4k3/8/8/8/8/8/8/4K3 w - - am Kd3;"},
+		{"negative hmvc", "4k3/8/8/8/8/8/8/4K3 w - - hmvc -1;"},
+		{"zero fmvn", "4k3/8/8/8/8/8/8/4K3 w - - fmvn 0;"},
+		{"two acd operands", "4k3/8/8/8/8/8/8/4K3 w - - acd 1 2;"},
+		{"ce not a number", "4k3/8/8/8/8/8/8/4K3 w - - ce high;"},
+	}
+	for _, tt := range tests {
+		if _, err := ParseEPD(tt.epd); !errors.Is(err, ErrInvalidEPD) {
+			t.Errorf("%s: %s: got %v, want ErrInvalidEPD", tt.name, tt.epd, err)
+		}
+	}
+}
+
+func TestEPDSetters(t *testing.T) {
+	e, err := ParseEPD("5k2/8/8/8/8/8/8/4K2R w K -")
+	if err != nil {
+		t.Fatal(err)
+	}
+	castle, _ := e.Board.ParseSAN("O-O")
+	rook, _ := e.Board.ParseSAN("Rh8+")
+	if err := e.SetBestMoves(castle, rook); err != nil {
+		t.Fatal(err)
+	}
+	e.SetID("castle or check")
+	e.SetAnalysisDepth(4)
+	e.SetCentipawnEval(900)
+	e.SetComment(1, "")
+	want := `5k2/8/8/8/8/8/8/4K2R w K - bm O-O+ Rh8+; id "castle or check"; acd 4; ce 900; c1 "";`
+	if got := e.String(); got != want {
+		t.Errorf("written as\n%s\nwant\n%s", got, want)
+	}
+
+	e.SetID("renamed")
+	e.DeleteOp("acd")
+	e.DeleteOp("c1")
+	want = `5k2/8/8/8/8/8/8/4K2R w K - bm O-O+ Rh8+; id "renamed"; ce 900;`
+	if got := e.String(); got != want {
+		t.Errorf("written as\n%s\nwant\n%s", got, want)
+	}
+	back, err := ParseEPD(want)
+	if err != nil || !reflect.DeepEqual(back.Operations, e.Operations) {
+		t.Errorf("read back as %v, %v", back.Operations, err)
+	}
+}
@@ -0,0 +1,65 @@
+package board
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/tygermarshall/blunderbuss/shared/pieces"
+)
+
+// Reasons a move can be rejected. Every error returned by MovePiece is an
+// *IllegalMoveError wrapping one of these, so callers can test for them with
+// errors.Is.
+var (
+	ErrOutOfBounds       = errors.New("square is off the board")
+	ErrEmptySquare       = errors.New("no piece on the starting square")
+	ErrWrongTurn         = errors.New("wrong side to move")
+	ErrInvalidMovement   = errors.New("piece cannot move that way")
+	ErrPathBlocked       = errors.New("path is blocked")
+	ErrOwnPieceCapture   = errors.New("cannot capture a piece of your own team")
+	ErrInvalidPromotion  = errors.New("invalid promotion")
+	ErrCastlingForbidden = errors.New("castling is not allowed")
+	ErrKingInCheck       = errors.New("move would leave the king in check")
+	ErrGameOver          = errors.New("game is over")
+)
+
+// IllegalMoveError is returned when a move breaks the rules. Reason is one
+// of the Err values above, or an error that wraps one, and Detail says
+// exactly what was wrong when there is more to say than Reason.
+type IllegalMoveError struct {
+	Move   Move
+	Reason error
+	Detail string
+}
+
+func (e *IllegalMoveError) Error() string {
+	if e.Detail != "" {
+		return "illegal move: " + e.Detail
+	}
+	return "illegal move: " + e.Reason.Error()
+}
+
+func (e *IllegalMoveError) Unwrap() error {
+	return e.Reason
+}
+
+// illegal returns an *IllegalMoveError for reason. MovePiece fills in the
+// move before returning it.
+func illegal(reason error, format string, args ...any) error {
+	return &IllegalMoveError{Reason: reason, Detail: fmt.Sprintf(format, args...)}
+}
+
+// WrongTurnError is the reason a piece moved while it is the other team's
+// turn. It wraps ErrWrongTurn.
+type WrongTurnError struct {
+	Team       pieces.Team
+	SideToMove pieces.Team
+}
+
+func (e *WrongTurnError) Error() string {
+	return fmt.Sprintf("it is %s's turn, %s cannot move", e.SideToMove, e.Team)
+}
+
+func (e *WrongTurnError) Unwrap() error {
+	return ErrWrongTurn
+}
@@ -0,0 +1,262 @@
+package board
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/tygermarshall/blunderbuss/shared/pieces"
+)
+
+// StartFEN is the standard starting position.
+const StartFEN = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+
+// ErrInvalidFEN is wrapped by every error ParseFEN returns.
+var ErrInvalidFEN = errors.New("invalid fen")
+
+func fenError(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrInvalidFEN, fmt.Sprintf(format, args...))
+}
+
+// ParseFEN loads a position written in Forsyth-Edwards Notation. Besides
+// the syntax it checks that the position makes sense: one king a side, no
+// pawns on the first or last rank, castling rights and the en passant
+// square that match the pieces, and the side not to move not in check.
+func ParseFEN(fen string) (Board, error) {
+	fields := strings.Fields(fen)
+	if len(fields) != 6 {
+		return Board{}, fenError("expected 6 fields, got %d", len(fields))
+	}
+	b, err := parsePosition(fields[:4])
+	if err != nil {
+		return Board{}, err
+	}
+	if b.HalfmoveClock, err = strconv.Atoi(fields[4]); err != nil || b.HalfmoveClock < 0 {
+		return Board{}, fenError("halfmove clock %q is not a non-negative number", fields[4])
+	}
+	if b.FullmoveNumber, err = strconv.Atoi(fields[5]); err != nil || b.FullmoveNumber < 1 {
+		return Board{}, fenError("fullmove number %q is not a positive number", fields[5])
+	}
+	return b, nil
+}
+
+// parsePosition reads the placement, side to move, castling and en passant
+// fields shared by FEN and EPD and validates the resulting position.
+func parsePosition(fields []string) (Board, error) {
+	var b Board
+	if err := b.parsePlacement(fields[0]); err != nil {
+		return b, err
+	}
+
+	switch fields[1] {
+	case "w":
+		b.SideToMove = pieces.White
+	case "b":
+		b.SideToMove = pieces.Black
+	default:
+		return b, fenError("side to move must be w or b, got %q", fields[1])
+	}
+
+	if err := b.parseCastling(fields[2]); err != nil {
+		return b, err
+	}
+	if err := b.parseEnPassant(fields[3]); err != nil {
+		return b, err
+	}
+
+	for _, team := range [2]pieces.Team{pieces.White, pieces.Black} {
+		if n := b.count(pieces.King, team); n != 1 {
+			return b, fenError("%s has %d kings", team, n)
+		}
+	}
+	if b.inCheck(b.SideToMove.Opponent()) {
+		return b, fenError("%s is in check but it is %s's turn", b.SideToMove.Opponent(), b.SideToMove)
+	}
+	b.ResetHash()
+	return b, nil
+}
+
+func (b *Board) parsePlacement(placement string) error {
+	rows := strings.Split(placement, "/")
+	if len(rows) != 8 {
+		return fenError("placement has %d ranks, want 8", len(rows))
+	}
+	for i, row := range rows {
+		rank := 7 - i
+		file := 0
+		lastWasDigit := false
+		for _, r := range row {
+			if file >= 8 {
+				return fenError("rank %d has more than 8 files", rank+1)
+			}
+			if r >= '1' && r <= '8' {
+				if lastWasDigit {
+					return fenError("rank %d has two digits in a row", rank+1)
+				}
+				lastWasDigit = true
+				file += int(r - '0')
+				if file > 8 {
+					return fenError("rank %d has more than 8 files", rank+1)
+				}
+				continue
+			}
+			lastWasDigit = false
+			piece, ok := pieceFromFEN(r)
+			if !ok {
+				return fenError("rank %d has unknown piece %q", rank+1, r)
+			}
+			if piece.Type == pieces.Pawn && (rank == 0 || rank == 7) {
+				return fenError("pawn on rank %d", rank+1)
+			}
+			b.setPiece(NewSquare(file, rank), piece)
+			file++
+		}
+		if file != 8 {
+			return fenError("rank %d has %d files, want 8", rank+1, file)
+		}
+	}
+	return nil
+}
+
+func (b *Board) parseCastling(field string) error {
+	if field == "-" {
+		return nil
+	}
+	order := "KQkq"
+	rights := [4]CastlingRights{WhiteKingside, WhiteQueenside, BlackKingside, BlackQueenside}
+	next := 0
+	for _, r := range field {
+		i := strings.IndexRune(order, r)
+		if i < next {
+			return fenError("castling rights %q are not a subset of KQkq in order", field)
+		}
+		b.Castling |= rights[i]
+		next = i + 1
+	}
+
+	for _, team := range [2]pieces.Team{pieces.White, pieces.Black} {
+		rank := homeRank(team)
+		kingside, queenside := WhiteKingside, WhiteQueenside
+		if team == pieces.Black {
+			kingside, queenside = BlackKingside, BlackQueenside
+		}
+		if b.Castling&(kingside|queenside) != 0 && !b.hasPiece(NewSquare(4, rank), pieces.King, team) {
+			return fenError("%s may castle but its king has moved", team)
+		}
+		if b.Castling.Has(kingside) && !b.hasPiece(NewSquare(7, rank), pieces.Rook, team) {
+			return fenError("%s may castle kingside but has no rook in the corner", team)
+		}
+		if b.Castling.Has(queenside) && !b.hasPiece(NewSquare(0, rank), pieces.Rook, team) {
+			return fenError("%s may castle queenside but has no rook in the corner", team)
+		}
+	}
+	return nil
+}
+
+func (b *Board) parseEnPassant(field string) error {
+	b.EnPassant = NoSquare
+	if field == "-" {
+		return nil
+	}
+	ep, err := ParseSquare(field)
+	if err != nil {
+		return fenError("en passant square %q is not a square", field)
+	}
+	// the pawn that just double pushed belongs to the side not to move and
+	// stands one rank past the skipped square
+	mover := b.SideToMove.Opponent()
+	dir, startRank, _ := pawnRanks(mover)
+	if ep.Rank() != startRank+dir {
+		return fenError("en passant square %s is not on the rank %s skips", field, mover)
+	}
+	if !b.hasPiece(ep.Offset(0, dir), pieces.Pawn, mover) {
+		return fenError("en passant square %s has no %s pawn in front of it", field, mover)
+	}
+	if b.Occupied()&(squareBB(ep)|squareBB(NewSquare(ep.File(), startRank))) != 0 {
+		return fenError("en passant square %s is not behind an empty path", field)
+	}
+	b.EnPassant = ep
+	return nil
+}
+
+// FEN writes the board in Forsyth-Edwards Notation.
+func (b Board) FEN() string {
+	var sb strings.Builder
+	sb.WriteString(b.placementFEN())
+	sb.WriteByte(' ')
+	sb.WriteString(b.stateFEN())
+	fmt.Fprintf(&sb, " %d %d", b.HalfmoveClock, b.FullmoveNumber)
+	return sb.String()
+}
+
+func (b Board) placementFEN() string {
+	var sb strings.Builder
+	for rank := 7; rank >= 0; rank-- {
+		if rank < 7 {
+			sb.WriteByte('/')
+		}
+		empty := 0
+		for file := 0; file < 8; file++ {
+			p := b.PieceAt(NewSquare(file, rank))
+			if p.Type == pieces.Empty {
+				empty++
+				continue
+			}
+			if empty > 0 {
+				sb.WriteByte(byte('0' + empty))
+				empty = 0
+			}
+			sb.WriteByte(pieceFEN(p))
+		}
+		if empty > 0 {
+			sb.WriteByte(byte('0' + empty))
+		}
+	}
+	return sb.String()
+}
+
+// stateFEN writes the side to move, castling and en passant fields.
+func (b Board) stateFEN() string {
+	side := "w"
+	if b.SideToMove == pieces.Black {
+		side = "b"
+	}
+	castling := ""
+	for i, r := range [4]CastlingRights{WhiteKingside, WhiteQueenside, BlackKingside, BlackQueenside} {
+		if b.Castling.Has(r) {
+			castling += string("KQkq"[i])
+		}
+	}
+	if castling == "" {
+		castling = "-"
+	}
+	return side + " " + castling + " " + b.EnPassant.String()
+}
+
+// pieceFromFEN returns the piece for a FEN letter: upper case for white
+// and lower case for black.
+func pieceFromFEN(r rune) (pieces.Piece, bool) {
+	i := strings.IndexRune("PNBRQKpnbrqk", r)
+	if i < 0 {
+		return pieces.Piece{}, false
+	}
+	team := pieces.White
+	if i >= 6 {
+		team = pieces.Black
+	}
+	return pieces.Piece{Type: pieces.PieceType(i % 6), Team: team}, true
+}
+
+func pieceFEN(p pieces.Piece) byte {
+	c := "PNBRQK"[p.Type]
+	if p.Team == pieces.Black {
+		c += 'a' - 'A'
+	}
+	return c
+}
+
+// count returns how many pieces of the given type team has.
+func (b Board) count(pt pieces.PieceType, team pieces.Team) int {
+	return b.pieceBB[team][pt].Count()
+}
@@ -0,0 +1,74 @@
+package board
+
+import (
+	"errors"
+	"testing"
+)
+
+// Every reference position, and every position one move after it, is
+// written back as the FEN it was read from and reads back as the same
+// board.
+func TestFENRoundTrip(t *testing.T) {
+	for _, p := range PerftPositions {
+		b, err := ParseFEN(p.FEN)
+		if err != nil {
+			t.Fatalf("%s: %v", p.Name, err)
+		}
+		if got := b.FEN(); got != p.FEN {
+			t.Errorf("%s: written as %s, want %s", p.Name, got, p.FEN)
+		}
+		for _, m := range b.LegalMoves() {
+			next := b
+			next.Make(m)
+			back, err := ParseFEN(next.FEN())
+			if err != nil {
+				t.Fatalf("%s after %s: %v", p.FEN, m.UCI(), err)
+			}
+			if back != next {
+				t.Fatalf("%s after %s: %s reads back as %s", p.FEN, m.UCI(), next.FEN(), back.FEN())
+			}
+		}
+	}
+}
+
+func TestParseFENRejects(t *testing.T) {
+	tests := []struct {
+		name, fen string
+	}{
+		{"five fields", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0"},
+		{"seven ranks", "rnbqkbnr/pppppppp/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"},
+		{"short rank", "rnbqkbnr/ppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"},
+		{"digit 9", "rnbqkbnr/pppppppp/9/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"},
+		{"long rank of pieces", "rnbqkbnrr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"},
+		{"two digits in a row", "rnbqkbnr/pppppppp/44/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"},
+		{"unknown piece", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBXKBNR w KQkq - 0 1"},
+		{"no white king", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQQBNR w kq - 0 1"},
+		{"two white kings", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBKKBNR w kq - 0 1"},
+		{"two black kings", "4k3/8/8/8/8/8/8/k3K3 w - - 0 1"},
+		{"white pawn on rank 1", "4k3/8/8/8/8/8/8/P3K3 w - - 0 1"},
+		{"black pawn on rank 8", "p3k3/8/8/8/8/8/8/4K3 w - - 0 1"},
+		{"white pawn on rank 8", "P3k3/8/8/8/8/8/8/4K3 b - - 0 1"},
+		{"bad side to move", "4k3/8/8/8/8/8/8/4K3 x - - 0 1"},
+		{"side not to move in check", "4k3/8/8/8/8/8/8/4R1K1 w - - 0 1"},
+		{"castling rights out of order", "r3k2r/8/8/8/8/8/8/R3K2R w QK - 0 1"},
+		{"unknown castling right", "r3k2r/8/8/8/8/8/8/R3K2R w KX - 0 1"},
+		{"castling twice", "r3k2r/8/8/8/8/8/8/R3K2R w KK - 0 1"},
+		{"castling without the king", "r3k2r/8/8/8/8/8/8/R4K1R w K - 0 1"},
+		{"castling without the rook", "r3k2r/8/8/8/8/8/8/R3K3 w K - 0 1"},
+		{"black castling without the rook", "4k2r/8/8/8/8/8/8/R3K2R w q - 0 1"},
+		{"en passant not a square", "4k3/8/8/8/4P3/8/8/4K3 b - e9 0 1"},
+		{"en passant on the wrong rank", "4k3/8/8/8/4P3/8/8/4K3 b - e4 0 1"},
+		{"en passant for the side to move", "4k3/8/8/8/4P3/8/8/4K3 w - e3 0 1"},
+		{"en passant with no pawn", "4k3/8/8/8/8/8/8/4K3 b - e3 0 1"},
+		{"en passant with the path blocked", "4k3/8/8/8/4P3/4N3/8/4K3 b - e3 0 1"},
+		{"en passant from an occupied square", "4k3/8/8/8/4P3/8/4P3/4K3 b - e3 0 1"},
+		{"negative halfmove clock", "4k3/8/8/8/8/8/8/4K3 w - - -1 1"},
+		{"zero fullmove number", "4k3/8/8/8/8/8/8/4K3 w - - 0 0"},
+		{"fullmove number not a number", "4k3/8/8/8/8/8/8/4K3 w - - 0 x"},
+	}
+	for _, tt := range tests {
+		if _, err := ParseFEN(tt.fen); !errors.Is(err, ErrInvalidFEN) {
+			t.Errorf("%s: %s: got %v, want ErrInvalidFEN", tt.name, tt.fen, err)
+		}
+	}
+}
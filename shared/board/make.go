@@ -0,0 +1,71 @@
+package board
+
+import (
+	"github.com/tygermarshall/blunderbuss/shared/pieces"
+)
+
+// Undo records what Make changed so that Unmake can take the move back.
+type Undo struct {
+	// Move is the move that was made.
+	Move Move
+
+	castling       CastlingRights
+	enPassant      Square
+	halfmoveClock  int
+	fullmoveNumber int
+	hash           uint64
+}
+
+// Make plays m on b in place and returns what Unmake needs to restore the
+// board. Unlike MovePiece it does no checking: m must be a legal move for
+// the side to move with its piece, captured piece and flags filled in, as
+// returned by LegalMoves or MovePiece. It is meant for searches, which make
+// and unmake moves millions of times on one board.
+func (b *Board) Make(m Move) Undo {
+	u := Undo{
+		Move:           m,
+		castling:       b.Castling,
+		enPassant:      b.EnPassant,
+		halfmoveClock:  b.HalfmoveClock,
+		fullmoveNumber: b.FullmoveNumber,
+		hash:           b.hash,
+	}
+	b.apply(m)
+	return u
+}
+
+// Unmake takes back the move recorded in u, which must be the last move
+// made on b, leaving b exactly as it was before Make.
+func (b *Board) Unmake(u Undo) {
+	m := u.Move
+	mover := m.Piece.Team
+
+	rank := homeRank(mover)
+	switch {
+	case m.Flags.Has(FlagKingsideCastle):
+		b.shiftPiece(NewSquare(5, rank), NewSquare(7, rank))
+	case m.Flags.Has(FlagQueensideCastle):
+		b.shiftPiece(NewSquare(3, rank), NewSquare(0, rank))
+	}
+
+	if m.Flags.Has(FlagPromotion) {
+		b.removePiece(m.To)
+		b.addPiece(m.From, pieces.Pawn, mover)
+	} else {
+		b.shiftPiece(m.To, m.From)
+	}
+
+	switch {
+	case m.Flags.Has(FlagEnPassant):
+		b.addPiece(NewSquare(m.To.File(), m.From.Rank()), m.Captured.Type, m.Captured.Team)
+	case m.Flags.Has(FlagCapture):
+		b.addPiece(m.To, m.Captured.Type, m.Captured.Team)
+	}
+
+	b.SideToMove = mover
+	b.Castling = u.castling
+	b.EnPassant = u.enPassant
+	b.HalfmoveClock = u.halfmoveClock
+	b.FullmoveNumber = u.fullmoveNumber
+	b.hash = u.hash
+}
@@ -0,0 +1,55 @@
+package board
+
+import (
+	"testing"
+)
+
+// makeUnmakeNodes caps the size of the tree walked from each reference
+// position.
+const makeUnmakeNodes = 200000
+
+// TestMakeUnmake walks the legal move tree of every reference position with
+// Make and Unmake on one board, checking at every node that Unmake leaves
+// the board identical to how it was before Make: pieces, bitboards, hash,
+// clocks, castling rights and en passant square.
+func TestMakeUnmake(t *testing.T) {
+	for _, p := range PerftPositions {
+		t.Run(p.Name, func(t *testing.T) {
+			b, err := ParseFEN(p.FEN)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, want := range p.Nodes {
+				if want > makeUnmakeNodes {
+					break
+				}
+				if got := walkMakeUnmake(t, &b, i+1); got != want {
+					t.Errorf("depth %d: got %d nodes, want %d", i+1, got, want)
+				}
+			}
+		})
+	}
+}
+
+// walkMakeUnmake counts the leaf nodes depth plies under b, failing t if a
+// move is not taken back exactly.
+func walkMakeUnmake(t *testing.T, b *Board, depth int) int64 {
+	t.Helper()
+	if depth == 0 {
+		return 1
+	}
+	var nodes int64
+	for _, m := range b.LegalMoves() {
+		before := *b
+		u := b.Make(m)
+		if b.hash != b.computeHash() {
+			t.Fatalf("%s after %s: incremental hash %x, want %x", before.FEN(), m.UCI(), b.hash, b.computeHash())
+		}
+		nodes += walkMakeUnmake(t, b, depth-1)
+		b.Unmake(u)
+		if *b != before {
+			t.Fatalf("%s: unmaking %s left %s", before.FEN(), m.UCI(), b.FEN())
+		}
+	}
+	return nodes
+}
@@ -0,0 +1,55 @@
+package board
+
+import (
+	"github.com/tygermarshall/blunderbuss/shared/pieces"
+)
+
+// MoveFlags records what kind of move a Move was.
+type MoveFlags uint8
+
+const (
+	FlagCapture MoveFlags = 1 << iota
+	FlagDoublePush
+	FlagEnPassant
+	FlagKingsideCastle
+	FlagQueensideCastle
+	FlagPromotion
+	// FlagCheck is set when the move leaves the opponent in check.
+	FlagCheck
+)
+
+// Has reports whether every flag in f is set.
+func (m MoveFlags) Has(f MoveFlags) bool {
+	return m&f == f
+}
+
+// Move is a single move of a piece from one square to another.
+//
+// When a Move is passed to MovePiece only From, To and Promotion are read.
+// Promotion is the piece a pawn becomes when it reaches the last rank, and
+// pieces.Empty for every other move. The Move returned by MovePiece, and
+// every Move from LegalMoves, also records the piece that moved, the piece
+// it captured (an empty piece if none) and its flags.
+type Move struct {
+	From      Square           `json:"from"`
+	To        Square           `json:"to"`
+	Piece     pieces.Piece     `json:"piece"`
+	Captured  pieces.Piece     `json:"captured"`
+	Promotion pieces.PieceType `json:"promotion"`
+	Flags     MoveFlags        `json:"flags"`
+}
+
+// NewMove returns a move from one square to another with no promotion.
+func NewMove(from, to Square) Move {
+	return Move{From: from, To: to, Promotion: pieces.Empty}
+}
+
+// IsCapture reports whether the move captured a piece, including en passant.
+func (m Move) IsCapture() bool {
+	return m.Flags.Has(FlagCapture)
+}
+
+// IsCastle reports whether the move was castling on either side.
+func (m Move) IsCastle() bool {
+	return m.Flags&(FlagKingsideCastle|FlagQueensideCastle) != 0
+}
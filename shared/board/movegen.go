@@ -0,0 +1,236 @@
+package board
+
+import (
+	"github.com/tygermarshall/blunderbuss/shared/pieces"
+)
+
+var promotionTypes = [4]pieces.PieceType{pieces.Queen, pieces.Rook, pieces.Bishop, pieces.Knight}
+
+// maxMoves is room for the pseudo-legal moves of any position; generating
+// into a buffer this size keeps the move list off the heap.
+const maxMoves = 256
+
+// LegalMoves returns every legal move for the side to move.
+func (b Board) LegalMoves() []Move {
+	var buf [maxMoves]Move
+	pseudo := b.pseudoLegalMoves(buf[:0])
+	moves := make([]Move, 0, len(pseudo))
+	for _, m := range pseudo {
+		if m, ok := b.legal(m); ok {
+			moves = append(moves, m)
+		}
+	}
+	return moves
+}
+
+// AppendLegalMoves appends the legal moves for the side to move to moves
+// and returns the extended slice. Unlike LegalMoves it does not work out
+// which moves give check, and it does not allocate when moves has room
+// for the result, which makes it the generator for searches.
+func (b *Board) AppendLegalMoves(moves []Move) []Move {
+	start := len(moves)
+	moves = b.pseudoLegalMoves(moves)
+	legal := moves[:start]
+	for _, m := range moves[start:] {
+		if b.isLegal(m) {
+			legal = append(legal, m)
+		}
+	}
+	return legal
+}
+
+// AppendLegalCaptures is AppendLegalMoves restricted to captures, en
+// passant included, and promotions.
+func (b *Board) AppendLegalCaptures(moves []Move) []Move {
+	start := len(moves)
+	moves = b.generate(moves, true)
+	legal := moves[:start]
+	for _, m := range moves[start:] {
+		if b.isLegal(m) {
+			legal = append(legal, m)
+		}
+	}
+	return legal
+}
+
+// LegalMovesFrom returns the legal moves of the piece on from. It returns
+// nil if from is off the board or does not hold a piece of the side to
+// move.
+func (b Board) LegalMovesFrom(from Square) []Move {
+	if !from.Valid() {
+		return nil
+	}
+	var moves []Move
+	for _, m := range b.LegalMoves() {
+		if m.From == from {
+			moves = append(moves, m)
+		}
+	}
+	return moves
+}
+
+// legal reports whether the pseudo-legal move m leaves the mover's king
+// safe, and returns it with FlagCheck set if it checks the opponent.
+func (b *Board) legal(m Move) (Move, bool) {
+	next := *b
+	next.apply(m)
+	if next.inCheck(b.SideToMove) {
+		return m, false
+	}
+	if next.inCheck(next.SideToMove) {
+		m.Flags |= FlagCheck
+	}
+	return m, true
+}
+
+// isLegal reports whether the pseudo-legal move m leaves the mover's king
+// safe.
+func (b *Board) isLegal(m Move) bool {
+	next := *b
+	next.apply(m)
+	return !next.inCheck(b.SideToMove)
+}
+
+// pseudoLegalMoves appends the moves of the side to move that follow the
+// rules MovePiece enforces, except that they may leave the mover's own
+// king in check. Castling is only generated when it is fully legal. The
+// moves have their piece, captured piece and flags filled in, apart from
+// FlagCheck.
+func (b *Board) pseudoLegalMoves(moves []Move) []Move {
+	return b.generate(moves, false)
+}
+
+// generate appends the pseudo-legal moves of the side to move, or only
+// its captures and promotions if capturesOnly is set.
+func (b *Board) generate(moves []Move, capturesOnly bool) []Move {
+	us := b.SideToMove
+	own, enemy := b.teamBB[us], b.teamBB[us.Opponent()]
+	occupied := own | enemy
+	targetMask := ^own
+	if capturesOnly {
+		targetMask = enemy
+	}
+
+	moves = b.pawnMoves(moves, occupied, enemy, capturesOnly)
+	for _, pt := range [5]pieces.PieceType{pieces.Knight, pieces.Bishop, pieces.Rook, pieces.Queen, pieces.King} {
+		for from := b.pieceBB[us][pt]; from != 0; {
+			sq := from.Pop()
+			var targets Bitboard
+			switch pt {
+			case pieces.Knight:
+				targets = knightAttacks[sq]
+			case pieces.Bishop:
+				targets = BishopAttacks(sq, occupied)
+			case pieces.Rook:
+				targets = RookAttacks(sq, occupied)
+			case pieces.Queen:
+				targets = QueenAttacks(sq, occupied)
+			case pieces.King:
+				targets = kingAttacks[sq]
+			}
+			for targets &= targetMask; targets != 0; {
+				moves = b.appendMove(moves, sq, targets.Pop(), pt, 0)
+			}
+		}
+	}
+	if capturesOnly {
+		return moves
+	}
+	return b.castlingMoves(moves, occupied)
+}
+
+// appendMove appends the move of the side to move's piece of type pt from
+// one square to another, marking it a capture if an enemy piece stands
+// there.
+func (b *Board) appendMove(moves []Move, from, to Square, pt pieces.PieceType, flags MoveFlags) []Move {
+	m := Move{
+		From:      from,
+		To:        to,
+		Piece:     pieces.Piece{Type: pt, Team: b.SideToMove},
+		Captured:  b.PieceAt(to),
+		Promotion: pieces.Empty,
+		Flags:     flags,
+	}
+	if m.Captured.Type != pieces.Empty {
+		m.Flags |= FlagCapture
+	}
+	return append(moves, m)
+}
+
+// pawnMoves appends the pushes, captures, en passant captures and
+// promotions of the side to move's pawns. If capturesOnly is set, the only
+// pushes it appends are promotions.
+func (b *Board) pawnMoves(moves []Move, occupied, enemy Bitboard, capturesOnly bool) []Move {
+	us := b.SideToMove
+	dir, startRank, lastRank := pawnRanks(us)
+	step := Square(8 * dir)
+	add := func(from, to Square, flags MoveFlags) {
+		if to.Rank() != lastRank {
+			moves = b.appendMove(moves, from, to, pieces.Pawn, flags)
+			return
+		}
+		for _, pt := range promotionTypes {
+			moves = b.appendMove(moves, from, to, pieces.Pawn, flags|FlagPromotion)
+			moves[len(moves)-1].Promotion = pt
+		}
+	}
+
+	for pawns := b.pieceBB[us][pieces.Pawn]; pawns != 0; {
+		from := pawns.Pop()
+		if one := from + step; !occupied.Has(one) && (!capturesOnly || one.Rank() == lastRank) {
+			add(from, one, 0)
+			if two := one + step; !capturesOnly && from.Rank() == startRank && !occupied.Has(two) {
+				add(from, two, FlagDoublePush)
+			}
+		}
+		for targets := pawnAttacks[us][from] & enemy; targets != 0; {
+			add(from, targets.Pop(), 0)
+		}
+		if b.EnPassant != NoSquare && pawnAttacks[us][from].Has(b.EnPassant) {
+			moves = append(moves, Move{
+				From:      from,
+				To:        b.EnPassant,
+				Piece:     pieces.Piece{Type: pieces.Pawn, Team: us},
+				Captured:  b.PieceAt(NewSquare(b.EnPassant.File(), from.Rank())),
+				Promotion: pieces.Empty,
+				Flags:     FlagCapture | FlagEnPassant,
+			})
+		}
+	}
+	return moves
+}
+
+// castlingMoves appends the side to move's legal castling moves: the
+// right must remain, the squares between king and rook must be empty, and
+// the king may not start on, pass over or land on an attacked square.
+func (b *Board) castlingMoves(moves []Move, occupied Bitboard) []Move {
+	us := b.SideToMove
+	rank := homeRank(us)
+	king := NewSquare(4, rank)
+	kingside, queenside := WhiteKingside, WhiteQueenside
+	if us == pieces.Black {
+		kingside, queenside = BlackKingside, BlackQueenside
+	}
+	if b.Castling&(kingside|queenside) == 0 || !b.hasPiece(king, pieces.King, us) {
+		return moves
+	}
+	enemy := us.Opponent()
+	if b.isAttacked(king, enemy) {
+		return moves
+	}
+
+	f, g := NewSquare(5, rank), NewSquare(6, rank)
+	if b.Castling.Has(kingside) && b.hasPiece(NewSquare(7, rank), pieces.Rook, us) &&
+		occupied&(squareBB(f)|squareBB(g)) == 0 &&
+		!b.isAttacked(f, enemy) && !b.isAttacked(g, enemy) {
+		moves = b.appendMove(moves, king, g, pieces.King, FlagKingsideCastle)
+	}
+
+	bq, c, d := NewSquare(1, rank), NewSquare(2, rank), NewSquare(3, rank)
+	if b.Castling.Has(queenside) && b.hasPiece(NewSquare(0, rank), pieces.Rook, us) &&
+		occupied&(squareBB(bq)|squareBB(c)|squareBB(d)) == 0 &&
+		!b.isAttacked(d, enemy) && !b.isAttacked(c, enemy) {
+		moves = b.appendMove(moves, king, c, pieces.King, FlagQueensideCastle)
+	}
+	return moves
+}
@@ -0,0 +1,97 @@
+package board
+
+import (
+	"testing"
+
+	"github.com/tygermarshall/blunderbuss/shared/pieces"
+)
+
+// TestMovePieceAgreesWithLegalMoves tries every move of every piece of the
+// side to move, to every square and with every promotion, in the reference
+// positions and the positions one move after them. MovePiece must accept
+// exactly the moves LegalMoves generates and apply them as generated, and
+// the rule checks that explain a rejection must reject exactly the others.
+func TestMovePieceAgreesWithLegalMoves(t *testing.T) {
+	for _, p := range PerftPositions {
+		t.Run(p.Name, func(t *testing.T) {
+			root, err := ParseFEN(p.FEN)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkAgreement(t, root)
+			for _, m := range root.LegalMoves() {
+				next, _, err := root.MovePiece(m)
+				if err != nil {
+					t.Fatalf("%s: %s: %v", root.FEN(), m.UCI(), err)
+				}
+				checkAgreement(t, next)
+			}
+		})
+	}
+}
+
+func checkAgreement(t *testing.T, b Board) {
+	t.Helper()
+	legal := make(map[Move]Move)
+	for _, m := range b.LegalMoves() {
+		legal[Move{From: m.From, To: m.To, Promotion: m.Promotion}] = m
+	}
+	accepted := 0
+	for from := A1; from <= H8; from++ {
+		piece := b.PieceAt(from)
+		if piece.Type == pieces.Empty || piece.Team != b.SideToMove {
+			continue
+		}
+		promotions := []pieces.PieceType{pieces.Empty}
+		if piece.Type == pieces.Pawn {
+			promotions = append(promotions, promotionTypes[:]...)
+		}
+		for to := A1; to <= H8; to++ {
+			for _, promotion := range promotions {
+				m := Move{From: from, To: to, Promotion: promotion}
+				want, isLegal := legal[m]
+				if rulesErr := b.whyIllegal(m); (rulesErr == nil) != isLegal {
+					t.Fatalf("%s: %s: generated %v, but the rules say %v", b.FEN(), m.UCI(), isLegal, rulesErr)
+				}
+				next, applied, err := b.MovePiece(m)
+				if !isLegal {
+					if err == nil {
+						t.Fatalf("%s: MovePiece accepted %s, which is not generated", b.FEN(), m.UCI())
+					}
+					continue
+				}
+				accepted++
+				if err != nil {
+					t.Fatalf("%s: MovePiece rejected generated move %s: %v", b.FEN(), m.UCI(), err)
+				}
+				if applied != want {
+					t.Fatalf("%s: MovePiece applied %+v, generated %+v", b.FEN(), applied, want)
+				}
+				wantNext := b
+				wantNext.apply(want)
+				if next != wantNext {
+					t.Fatalf("%s: MovePiece(%s) gave %s, want %s", b.FEN(), m.UCI(), next.FEN(), wantNext.FEN())
+				}
+			}
+		}
+	}
+	if accepted != len(legal) {
+		t.Fatalf("%s: MovePiece accepted %d moves, LegalMoves generates %d", b.FEN(), accepted, len(legal))
+	}
+}
+
+func BenchmarkLegalMoves(b *testing.B) {
+	boards := make([]Board, len(PerftPositions))
+	for i, p := range PerftPositions {
+		var err error
+		if boards[i], err = ParseFEN(p.FEN); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, board := range boards {
+			board.LegalMoves()
+		}
+	}
+}
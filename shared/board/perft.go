@@ -0,0 +1,100 @@
+package board
+
+// PerftPosition is a reference position with known perft node counts.
+// Nodes[i] is the number of leaf nodes at depth i+1.
+type PerftPosition struct {
+	Name  string
+	FEN   string
+	Nodes []int64
+}
+
+// PerftPositions are the standard positions used to check move generators.
+// Between them they cover castling, en passant, promotions and discovered
+// checks.
+var PerftPositions = []PerftPosition{
+	{
+		Name:  "start",
+		FEN:   "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+		Nodes: []int64{20, 400, 8902, 197281, 4865609, 119060324},
+	},
+	{
+		Name:  "kiwipete",
+		FEN:   "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+		Nodes: []int64{48, 2039, 97862, 4085603, 193690690},
+	},
+	{
+		Name:  "position3",
+		FEN:   "8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1",
+		Nodes: []int64{14, 191, 2812, 43238, 674624, 11030083},
+	},
+	{
+		Name:  "position4",
+		FEN:   "r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1",
+		Nodes: []int64{6, 264, 9467, 422333, 15833292},
+	},
+	{
+		Name:  "position4-mirrored",
+		FEN:   "r2q1rk1/pP1p2pp/Q4n2/bbp1p3/Np6/1B3NBn/pPPP1PPP/R3K2R b KQ - 0 1",
+		Nodes: []int64{6, 264, 9467, 422333, 15833292},
+	},
+	{
+		Name:  "position5",
+		FEN:   "rnbq1k1r/pp1Pbppp/2p5/8/2B5/8/PPP1NnPP/RNBQK2R w KQ - 1 8",
+		Nodes: []int64{44, 1486, 62379, 2103487, 89941194},
+	},
+	{
+		Name:  "position6",
+		FEN:   "r4rk1/1pp1qppp/p1np1n2/2b1p1B1/2B1P1b1/P1NP1N2/1PP1QPPP/R4RK1 w - - 0 10",
+		Nodes: []int64{46, 2079, 89890, 3894594, 164075551},
+	},
+}
+
+// PerftBoard returns the board for the named reference position.
+func PerftBoard(name string) (Board, bool) {
+	for _, p := range PerftPositions {
+		if p.Name == name {
+			b, err := ParseFEN(p.FEN)
+			return b, err == nil
+		}
+	}
+	return Board{}, false
+}
+
+// Perft counts the leaf nodes of the legal move tree depth plies deep.
+func (b Board) Perft(depth int) int64 {
+	return b.perft(depth)
+}
+
+func (b *Board) perft(depth int) int64 {
+	if depth <= 0 {
+		return 1
+	}
+	var buf [maxMoves]Move
+	var nodes int64
+	for _, m := range b.pseudoLegalMoves(buf[:0]) {
+		next := *b
+		next.apply(m)
+		if next.inCheck(b.SideToMove) {
+			continue
+		}
+		if depth == 1 {
+			nodes++
+		} else {
+			nodes += next.perft(depth - 1)
+		}
+	}
+	return nodes
+}
+
+// Divide runs Perft(depth-1) after each legal move and returns the node
+// count under each one. Comparing the split against another engine narrows
+// a wrong total down to the move that causes it.
+func (b Board) Divide(depth int) map[Move]int64 {
+	counts := make(map[Move]int64)
+	for _, m := range b.LegalMoves() {
+		next := b
+		next.apply(m)
+		counts[m] = next.perft(depth - 1)
+	}
+	return counts
+}
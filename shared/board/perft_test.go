@@ -0,0 +1,48 @@
+package board
+
+import (
+	"testing"
+)
+
+// perftNodes caps the depth each reference position is checked to, so the
+// whole table runs in a few seconds. -short lowers it further.
+const perftNodes = 5000000
+
+func TestPerft(t *testing.T) {
+	limit := int64(perftNodes)
+	if testing.Short() {
+		limit = perftNodes / 10
+	}
+	for _, p := range PerftPositions {
+		t.Run(p.Name, func(t *testing.T) {
+			b, err := ParseFEN(p.FEN)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, want := range p.Nodes {
+				if want > limit {
+					break
+				}
+				if got := b.Perft(i + 1); got != want {
+					t.Errorf("depth %d: got %d nodes, want %d", i+1, got, want)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkPerft(b *testing.B) {
+	for _, p := range PerftPositions {
+		board, err := ParseFEN(p.FEN)
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Run(p.Name, func(b *testing.B) {
+			var nodes int64
+			for i := 0; i < b.N; i++ {
+				nodes += board.Perft(3)
+			}
+			b.ReportMetric(float64(nodes)/b.Elapsed().Seconds(), "nodes/s")
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package board
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/tygermarshall/blunderbuss/shared/pieces"
+)
+
+// ErrInvalidSAN is wrapped by every error ParseSAN returns.
+var ErrInvalidSAN = errors.New("invalid san")
+
+func sanError(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrInvalidSAN, fmt.Sprintf(format, args...))
+}
+
+// ParseSAN returns the legal move written in Standard Algebraic Notation,
+// such as "Nf3", "exd8=Q+" or "O-O". Check and mate suffixes and trailing
+// annotations like "!?" are accepted but not required, and a disambiguation
+// is only rejected if it does not single out one legal move.
+func (b Board) ParseSAN(san string) (Move, error) {
+	s := strings.TrimRight(san, "+#!?")
+	if s == "" {
+		return Move{}, sanError("empty move")
+	}
+
+	switch s {
+	case "O-O", "0-0":
+		return b.findCastle(san, FlagKingsideCastle)
+	case "O-O-O", "0-0-0":
+		return b.findCastle(san, FlagQueensideCastle)
+	}
+
+	promotion := pieces.Empty
+	if i := strings.IndexByte(s, '='); i >= 0 {
+		pt, ok := promotionFromSAN(s[i+1:])
+		if !ok {
+			return Move{}, sanError("%q has an invalid promotion", san)
+		}
+		promotion, s = pt, s[:i]
+	} else if n := len(s); n > 2 && s[n-1] >= 'A' && s[n-1] <= 'Z' && s[0] >= 'a' && s[0] <= 'h' {
+		// some tools drop the '=' and write e8Q
+		pt, ok := promotionFromSAN(s[n-1:])
+		if !ok {
+			return Move{}, sanError("%q has an invalid promotion", san)
+		}
+		promotion, s = pt, s[:n-1]
+	}
+
+	if len(s) < 2 {
+		return Move{}, sanError("%q has no destination square", san)
+	}
+	to, err := ParseSquare(s[len(s)-2:])
+	if err != nil {
+		return Move{}, sanError("%q has no destination square", san)
+	}
+	s = s[:len(s)-2]
+
+	pieceType := pieces.Pawn
+	if s != "" && strings.IndexByte("NBRQK", s[0]) >= 0 {
+		pieceType, _ = pieces.ParsePieceType(s[:1])
+		s = s[1:]
+	}
+	s = strings.TrimSuffix(s, "x")
+
+	fromFile, fromRank := -1, -1
+	for _, r := range s {
+		switch {
+		case r >= 'a' && r <= 'h' && fromFile < 0 && fromRank < 0:
+			fromFile = int(r - 'a')
+		case r >= '1' && r <= '8' && fromRank < 0:
+			fromRank = int(r - '1')
+		default:
+			return Move{}, sanError("%q has an invalid disambiguation", san)
+		}
+	}
+
+	var found []Move
+	for _, m := range b.LegalMoves() {
+		if m.Piece.Type != pieceType || m.To != to || m.Promotion != promotion {
+			continue
+		}
+		if (fromFile >= 0 && m.From.File() != fromFile) || (fromRank >= 0 && m.From.Rank() != fromRank) {
+			continue
+		}
+		found = append(found, m)
+	}
+	switch len(found) {
+	case 0:
+		return Move{}, sanError("%q is not a legal move", san)
+	case 1:
+		return found[0], nil
+	}
+	return Move{}, sanError("%q is ambiguous", san)
+}
+
+func (b Board) findCastle(san string, flag MoveFlags) (Move, error) {
+	for _, m := range b.LegalMoves() {
+		if m.Flags.Has(flag) {
+			return m, nil
+		}
+	}
+	return Move{}, sanError("%q is not a legal move", san)
+}
+
+func promotionFromSAN(s string) (pieces.PieceType, bool) {
+	if len(s) != 1 || strings.IndexByte("NBRQ", s[0]) < 0 {
+		return pieces.Empty, false
+	}
+	pt, err := pieces.ParsePieceType(s)
+	return pt, err == nil
+}
+
+// SAN writes a legal move in Standard Algebraic Notation, disambiguating
+// only as much as the position requires and adding "+" or "#" when the
+// move gives check or mate.
+func (b Board) SAN(m Move) (string, error) {
+	next, applied, err := b.movePiece(m)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	switch {
+	case applied.Flags.Has(FlagKingsideCastle):
+		sb.WriteString("O-O")
+	case applied.Flags.Has(FlagQueensideCastle):
+		sb.WriteString("O-O-O")
+	case applied.Piece.Type == pieces.Pawn:
+		if applied.IsCapture() {
+			sb.WriteByte(byte('a' + applied.From.File()))
+			sb.WriteByte('x')
+		}
+		sb.WriteString(applied.To.String())
+		if applied.Promotion != pieces.Empty {
+			sb.WriteByte('=')
+			sb.WriteByte(sanLetter(applied.Promotion))
+		}
+	default:
+		sb.WriteByte(sanLetter(applied.Piece.Type))
+		sb.WriteString(b.disambiguation(applied))
+		if applied.IsCapture() {
+			sb.WriteByte('x')
+		}
+		sb.WriteString(applied.To.String())
+	}
+
+	if next.IsCheckmate() {
+		sb.WriteByte('#')
+	} else if applied.Flags.Has(FlagCheck) {
+		sb.WriteByte('+')
+	}
+	return sb.String(), nil
+}
+
+// disambiguation returns the shortest prefix of m's starting square that
+// tells it apart from other pieces of the same type that can reach the
+// same square: the file if that is enough, else the rank, else both.
+func (b Board) disambiguation(m Move) string {
+	var others []Move
+	for _, o := range b.LegalMoves() {
+		if o.Piece.Type == m.Piece.Type && o.To == m.To && o.From != m.From {
+			others = append(others, o)
+		}
+	}
+	if len(others) == 0 {
+		return ""
+	}
+	sameFile, sameRank := false, false
+	for _, o := range others {
+		sameFile = sameFile || o.From.File() == m.From.File()
+		sameRank = sameRank || o.From.Rank() == m.From.Rank()
+	}
+	name := m.From.String()
+	switch {
+	case !sameFile:
+		return name[:1]
+	case !sameRank:
+		return name[1:]
+	}
+	return name
+}
+
+func sanLetter(pt pieces.PieceType) byte {
+	return "PNBRQK"[pt]
+}
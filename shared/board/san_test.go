@@ -0,0 +1,184 @@
+package board
+
+import (
+	"errors"
+	"testing"
+)
+
+const (
+	// rooks on a1 and h1 both reach d1
+	sanFileFEN = "1k6/8/8/8/8/8/4K3/R6R w - - 0 1"
+	// rooks on a1 and a5 both reach a3
+	sanRankFEN = "1k6/8/8/R7/8/8/4K3/R7 w - - 0 1"
+	// queens on a1, a3 and c1 all reach b2
+	sanSquareFEN = "8/7k/8/8/8/Q7/4K3/Q1Q5 w - - 0 1"
+	// knights on b1 and f3 both reach d2
+	sanKnightsFEN  = "k7/8/8/8/8/5N2/4K3/1N6 w - - 0 1"
+	sanPromoteFEN  = "2r5/1P5k/8/8/8/8/4K3/8 w - - 0 1"
+	sanCastlingFEN = "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1"
+)
+
+func TestParseSAN(t *testing.T) {
+	tests := []struct {
+		fen, san, uci string
+	}{
+		{StartFEN, "e4", "e2e4"},
+		{StartFEN, "Nf3", "g1f3"},
+		{StartFEN, "Nf3!?", "g1f3"},
+		{"rnbqkbnr/ppp1pppp/8/3p4/4P3/8/PPPP1PPP/RNBQKBNR w KQkq d6 0 2", "exd5", "e4d5"},
+		{sanFileFEN, "Rad1", "a1d1"},
+		{sanFileFEN, "Rhd1", "h1d1"},
+		{sanFileFEN, "Rhxd1", "h1d1"},
+		{sanRankFEN, "R1a3", "a1a3"},
+		{sanRankFEN, "R5a3", "a5a3"},
+		{sanSquareFEN, "Qa1b2", "a1b2"},
+		{sanSquareFEN, "Q3b2", "a3b2"},
+		{sanSquareFEN, "Qcb2", "c1b2"},
+		{sanKnightsFEN, "Nbd2", "b1d2"},
+		{sanKnightsFEN, "Nfd2", "f3d2"},
+		// over-disambiguated but still one move
+		{sanKnightsFEN, "Nf3d2", "f3d2"},
+		{sanPromoteFEN, "b8=Q", "b7b8q"},
+		{sanPromoteFEN, "b8Q", "b7b8q"},
+		{sanPromoteFEN, "b8=N", "b7b8n"},
+		{sanPromoteFEN, "bxc8=R", "b7c8r"},
+		{sanPromoteFEN, "bxc8B", "b7c8b"},
+		{sanCastlingFEN, "O-O", "e1g1"},
+		{sanCastlingFEN, "O-O-O", "e1c1"},
+		{sanCastlingFEN, "0-0", "e1g1"},
+		{sanCastlingFEN, "0-0-0+", "e1c1"},
+		{"r3k2r/8/8/8/8/8/8/R3K2R b KQkq - 0 1", "O-O", "e8g8"},
+		{"r3k2r/8/8/8/8/8/8/R3K2R b KQkq - 0 1", "O-O-O", "e8c8"},
+	}
+	for _, tt := range tests {
+		b, err := ParseFEN(tt.fen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m, err := b.ParseSAN(tt.san)
+		if err != nil {
+			t.Errorf("%s: %s: %v", tt.fen, tt.san, err)
+			continue
+		}
+		if m.UCI() != tt.uci {
+			t.Errorf("%s: %s parsed as %s, want %s", tt.fen, tt.san, m.UCI(), tt.uci)
+		}
+	}
+}
+
+func TestParseSANRejects(t *testing.T) {
+	tests := []struct {
+		fen, san, why string
+	}{
+		{StartFEN, "", "empty"},
+		{StartFEN, "+", "empty"},
+		{StartFEN, "e5", "pawns cannot move two squares from the third rank"},
+		{StartFEN, "Qh5", "blocked"},
+		{StartFEN, "O-O", "castling through pieces"},
+		{StartFEN, "Ke2", "own piece on the square"},
+		{StartFEN, "Nz3", "off the board"},
+		{StartFEN, "N", "no square"},
+		{sanFileFEN, "Rd1", "ambiguous"},
+		{sanRankFEN, "Ra3", "ambiguous"},
+		{sanSquareFEN, "Qab2", "ambiguous"},
+		{sanSquareFEN, "Q1b2", "ambiguous"},
+		{sanKnightsFEN, "Nd2", "ambiguous"},
+		{sanKnightsFEN, "Ncd2", "no knight on the c-file"},
+		{sanKnightsFEN, "N1a1d2", "two ranks"},
+		{sanPromoteFEN, "b8", "promotion is required"},
+		{sanPromoteFEN, "b8=K", "cannot promote to a king"},
+		{sanPromoteFEN, "b8=", "promotion without a piece"},
+		{sanPromoteFEN, "e2e4", "not SAN"},
+		// the bishop is pinned against the king
+		{"4k3/8/8/8/4r3/8/4B3/4K3 w - - 0 1", "Bd3", "pinned"},
+		{"4k3/8/8/8/8/8/8/4K2R w - - 0 1", "O-O-O", "no rook"},
+	}
+	for _, tt := range tests {
+		b, err := ParseFEN(tt.fen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m, err := b.ParseSAN(tt.san); !errors.Is(err, ErrInvalidSAN) {
+			t.Errorf("%s: %q (%s): got %s, %v, want ErrInvalidSAN", tt.fen, tt.san, tt.why, m.UCI(), err)
+		}
+	}
+}
+
+func TestSAN(t *testing.T) {
+	tests := []struct {
+		fen, uci, san string
+	}{
+		{StartFEN, "e2e4", "e4"},
+		{StartFEN, "g1f3", "Nf3"},
+		{"rnbqkbnr/ppp1pppp/8/3p4/4P3/8/PPPP1PPP/RNBQKBNR w KQkq d6 0 2", "e4d5", "exd5"},
+		{"rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR w KQkq f6 0 3", "e5f6", "exf6"},
+		{sanFileFEN, "a1d1", "Rad1"},
+		{sanFileFEN, "h1d1", "Rhd1"},
+		{sanFileFEN, "a1a7", "Ra7"},
+		{sanRankFEN, "a1a3", "R1a3"},
+		{sanRankFEN, "a5a3", "R5a3"},
+		{sanRankFEN, "a5a8", "Ra8+"},
+		{sanSquareFEN, "a1b2", "Qa1b2"},
+		{sanSquareFEN, "a3b2", "Q3b2"},
+		{sanSquareFEN, "c1b2", "Qcb2"},
+		{sanKnightsFEN, "b1d2", "Nbd2"},
+		{sanKnightsFEN, "f3d2", "Nfd2"},
+		{sanKnightsFEN, "f3g5", "Ng5"},
+		{sanPromoteFEN, "b7b8q", "b8=Q"},
+		{sanPromoteFEN, "b7b8n", "b8=N"},
+		{sanPromoteFEN, "b7c8r", "bxc8=R"},
+		{sanCastlingFEN, "e1g1", "O-O"},
+		{sanCastlingFEN, "e1c1", "O-O-O"},
+		{"4k3/8/8/8/8/8/8/R3K3 w - - 0 1", "a1a8", "Ra8+"},
+		{"6k1/5ppp/8/8/8/8/8/R3K3 w - - 0 1", "a1a8", "Ra8#"},
+		{"rnbqkbnr/pppp1ppp/8/4p3/6P1/5P2/PPPPP2P/RNBQKBNR b KQkq g3 0 2", "d8h4", "Qh4#"},
+		{"5k2/8/8/8/8/8/8/4K2R w K - 0 1", "e1g1", "O-O+"},
+	}
+	for _, tt := range tests {
+		b, err := ParseFEN(tt.fen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m, err := ParseUCI(tt.uci)
+		if err != nil {
+			t.Fatal(err)
+		}
+		san, err := b.SAN(m)
+		if err != nil {
+			t.Errorf("%s: %s: %v", tt.fen, tt.uci, err)
+			continue
+		}
+		if san != tt.san {
+			t.Errorf("%s: %s written as %s, want %s", tt.fen, tt.uci, san, tt.san)
+		}
+	}
+}
+
+// Every legal move in the reference positions and one move after them
+// reads back from its SAN as the same move.
+func TestSANRoundTrip(t *testing.T) {
+	check := func(b Board) {
+		for _, m := range b.LegalMoves() {
+			san, err := b.SAN(m)
+			if err != nil {
+				t.Fatalf("%s: %s: %v", b.FEN(), m.UCI(), err)
+			}
+			back, err := b.ParseSAN(san)
+			if err != nil || back.From != m.From || back.To != m.To || back.Promotion != m.Promotion {
+				t.Fatalf("%s: %s written as %s, read back as %s, %v", b.FEN(), m.UCI(), san, back.UCI(), err)
+			}
+		}
+	}
+	for _, p := range PerftPositions {
+		b, err := ParseFEN(p.FEN)
+		if err != nil {
+			t.Fatal(err)
+		}
+		check(b)
+		for _, m := range b.LegalMoves() {
+			next := b
+			next.Make(m)
+			check(next)
+		}
+	}
+}
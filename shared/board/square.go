@@ -0,0 +1,147 @@
+package board
+
+import (
+	"fmt"
+)
+
+// Square is one of the 64 squares of the board, numbered from a1 = 0
+// along each rank to h8 = 63. Files and ranks are counted from zero, so
+// e4 has file 4 and rank 3.
+type Square int8
+
+const (
+	A1 Square = iota
+	B1
+	C1
+	D1
+	E1
+	F1
+	G1
+	H1
+	A2
+	B2
+	C2
+	D2
+	E2
+	F2
+	G2
+	H2
+	A3
+	B3
+	C3
+	D3
+	E3
+	F3
+	G3
+	H3
+	A4
+	B4
+	C4
+	D4
+	E4
+	F4
+	G4
+	H4
+	A5
+	B5
+	C5
+	D5
+	E5
+	F5
+	G5
+	H5
+	A6
+	B6
+	C6
+	D6
+	E6
+	F6
+	G6
+	H6
+	A7
+	B7
+	C7
+	D7
+	E7
+	F7
+	G7
+	H7
+	A8
+	B8
+	C8
+	D8
+	E8
+	F8
+	G8
+	H8
+
+	// NoSquare marks the absence of a square, such as when no en passant
+	// capture is possible.
+	NoSquare Square = -1
+)
+
+// NewSquare returns the square on file and rank, both counted from zero.
+// It returns NoSquare if either is off the board.
+func NewSquare(file, rank int) Square {
+	if file < 0 || file > 7 || rank < 0 || rank > 7 {
+		return NoSquare
+	}
+	return Square(rank*8 + file)
+}
+
+// ParseSquare reads an algebraic square name such as "e4".
+func ParseSquare(s string) (Square, error) {
+	if len(s) != 2 || s[0] < 'a' || s[0] > 'h' || s[1] < '1' || s[1] > '8' {
+		return NoSquare, fmt.Errorf("%q is not a square", s)
+	}
+	return NewSquare(int(s[0]-'a'), int(s[1]-'1')), nil
+}
+
+// File returns the square's file from 0 (the a-file) to 7 (the h-file).
+func (s Square) File() int {
+	return int(s) % 8
+}
+
+// Rank returns the square's rank from 0 (rank 1) to 7 (rank 8).
+func (s Square) Rank() int {
+	return int(s) / 8
+}
+
+// Valid reports whether s is on the board.
+func (s Square) Valid() bool {
+	return s >= A1 && s <= H8
+}
+
+// Offset returns the square df files and dr ranks away from s, or NoSquare
+// if that is off the board.
+func (s Square) Offset(df, dr int) Square {
+	return NewSquare(s.File()+df, s.Rank()+dr)
+}
+
+// String returns the square's algebraic name, or "-" for NoSquare.
+func (s Square) String() string {
+	if !s.Valid() {
+		return "-"
+	}
+	return string([]byte{byte('a' + s.File()), byte('1' + s.Rank())})
+}
+
+// MarshalText writes the square's algebraic name, so squares appear as
+// "e4" in JSON.
+func (s Square) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText reads an algebraic square name, or "-" for NoSquare.
+func (s *Square) UnmarshalText(text []byte) error {
+	if string(text) == "-" {
+		*s = NoSquare
+		return nil
+	}
+	sq, err := ParseSquare(string(text))
+	if err != nil {
+		return err
+	}
+	*s = sq
+	return nil
+}
@@ -0,0 +1,49 @@
+package board
+
+import (
+	"github.com/tygermarshall/blunderbuss/shared/pieces"
+)
+
+// InCheck reports whether the side to move is in check.
+func (b Board) InCheck() bool {
+	return b.inCheck(b.SideToMove)
+}
+
+// IsCheckmate reports whether the side to move is in check and has no
+// legal move.
+func (b Board) IsCheckmate() bool {
+	return b.InCheck() && !b.hasLegalMove()
+}
+
+// IsStalemate reports whether the side to move is not in check but has no
+// legal move.
+func (b Board) IsStalemate() bool {
+	return !b.InCheck() && !b.hasLegalMove()
+}
+
+// inCheck reports whether team's king is attacked. A board without a king
+// for team is never in check.
+func (b *Board) inCheck(team pieces.Team) bool {
+	king, ok := b.kingSquare(team)
+	return ok && b.isAttacked(king, team.Opponent())
+}
+
+func (b *Board) kingSquare(team pieces.Team) (Square, bool) {
+	kings := b.pieceBB[team][pieces.King]
+	if kings == 0 {
+		return NoSquare, false
+	}
+	return kings.First(), true
+}
+
+// hasLegalMove reports whether the side to move has at least one legal
+// move.
+func (b *Board) hasLegalMove() bool {
+	var buf [maxMoves]Move
+	for _, m := range b.pseudoLegalMoves(buf[:0]) {
+		if b.isLegal(m) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,48 @@
+package board
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/tygermarshall/blunderbuss/shared/pieces"
+)
+
+// ErrInvalidUCI is wrapped by every error ParseUCI returns.
+var ErrInvalidUCI = errors.New("invalid uci move")
+
+// ParseUCI reads a move in the long algebraic form used by the Universal
+// Chess Interface: the from and to squares followed by a lowercase
+// promotion letter, e.g. "e2e4", "e1g1" or "e7e8q". Castling is written as
+// the king's move. ParseUCI only checks the syntax; MovePiece decides
+// whether the move is legal.
+func ParseUCI(s string) (Move, error) {
+	if len(s) != 4 && len(s) != 5 {
+		return Move{}, fmt.Errorf("%w: %q must be two squares and an optional promotion letter", ErrInvalidUCI, s)
+	}
+	from, err := ParseSquare(s[0:2])
+	if err != nil {
+		return Move{}, fmt.Errorf("%w: %q: %v", ErrInvalidUCI, s, err)
+	}
+	to, err := ParseSquare(s[2:4])
+	if err != nil {
+		return Move{}, fmt.Errorf("%w: %q: %v", ErrInvalidUCI, s, err)
+	}
+	m := NewMove(from, to)
+	if len(s) == 5 {
+		if strings.IndexByte("nbrq", s[4]) < 0 {
+			return Move{}, fmt.Errorf("%w: %q: promotion must be one of n, b, r or q", ErrInvalidUCI, s)
+		}
+		m.Promotion, _ = pieces.ParsePieceType(s[4:])
+	}
+	return m, nil
+}
+
+// UCI writes the move in UCI long algebraic form, e.g. "e2e4" or "e7e8q".
+func (m Move) UCI() string {
+	s := m.From.String() + m.To.String()
+	if m.Promotion != pieces.Empty {
+		s += strings.ToLower(string(sanLetter(m.Promotion)))
+	}
+	return s
+}
@@ -0,0 +1,64 @@
+package board
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/tygermarshall/blunderbuss/shared/pieces"
+)
+
+func TestParseUCI(t *testing.T) {
+	tests := []struct {
+		uci       string
+		from, to  Square
+		promotion pieces.PieceType
+	}{
+		{"e2e4", E2, E4, pieces.Empty},
+		{"e1g1", E1, G1, pieces.Empty},
+		{"h7h8q", H7, H8, pieces.Queen},
+		{"a2a1n", A2, A1, pieces.Knight},
+		{"b7c8r", B7, C8, pieces.Rook},
+		{"g2h1b", G2, H1, pieces.Bishop},
+	}
+	for _, tt := range tests {
+		m, err := ParseUCI(tt.uci)
+		if err != nil {
+			t.Errorf("%s: %v", tt.uci, err)
+			continue
+		}
+		if m.From != tt.from || m.To != tt.to || m.Promotion != tt.promotion {
+			t.Errorf("%s: got %s to %s promoting to %v", tt.uci, m.From, m.To, m.Promotion)
+		}
+		if m.UCI() != tt.uci {
+			t.Errorf("%s: written back as %s", tt.uci, m.UCI())
+		}
+	}
+}
+
+func TestParseUCIRejects(t *testing.T) {
+	for _, uci := range []string{
+		"", "e2", "e2e", "e2e4qq", "e2-e4", "i2e4", "e0e4", "e2e9", "E2E4",
+		"e7e8k", "e7e8p", "e7e8Q", "0000",
+	} {
+		if m, err := ParseUCI(uci); !errors.Is(err, ErrInvalidUCI) {
+			t.Errorf("%q: got %s, %v, want ErrInvalidUCI", uci, m.UCI(), err)
+		}
+	}
+}
+
+// ParseUCI only reads the squares; MovePiece fills in the rest, castling
+// included.
+func TestUCIMovesPlay(t *testing.T) {
+	b, err := ParseFEN("r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, _ := ParseUCI("e1c1")
+	next, applied, err := b.MovePiece(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !applied.Flags.Has(FlagQueensideCastle) || next.PieceAt(D1).Type != pieces.Rook {
+		t.Errorf("e1c1 applied as %+v, leaving %s", applied, next.FEN())
+	}
+}
@@ -0,0 +1,75 @@
+package board
+
+import (
+	"github.com/tygermarshall/blunderbuss/shared/pieces"
+)
+
+// Hash returns the position's Zobrist key. It covers the pieces, the side
+// to move, the castling rights and the en passant file, and uses the
+// Polyglot random table, so it matches the keys of Polyglot opening books.
+// As in Polyglot, the en passant file only counts when a pawn of the side
+// to move stands ready to capture onto it.
+//
+// The key is kept up to date by MovePiece. Boards from ParseFEN,
+// CreateDefaultBoard or JSON have it set; a Board built by hand does not
+// until ResetHash is called.
+func (b Board) Hash() uint64 {
+	return b.hash
+}
+
+// ResetHash computes the Zobrist key from scratch. It is only needed after
+// changing a Board's fields directly.
+func (b *Board) ResetHash() {
+	b.hash = b.computeHash()
+}
+
+func (b Board) computeHash() uint64 {
+	var h uint64
+	for sq := A1; sq <= H8; sq++ {
+		if p := b.PieceAt(sq); p.Type != pieces.Empty {
+			h ^= pieceKey(sq, p.Type, p.Team)
+		}
+	}
+	h ^= castlingKey(b.Castling)
+	h ^= b.enPassantKey()
+	if b.SideToMove == pieces.White {
+		h ^= polyglotRandom[780]
+	}
+	return h
+}
+
+// pieceKey returns the key for a piece of type pt and team standing on
+// sq.
+func pieceKey(sq Square, pt pieces.PieceType, team pieces.Team) uint64 {
+	kind := 2 * int(pt)
+	if team == pieces.White {
+		kind++
+	}
+	return polyglotRandom[64*kind+int(sq)]
+}
+
+func castlingKey(c CastlingRights) uint64 {
+	var h uint64
+	for i, r := range [4]CastlingRights{WhiteKingside, WhiteQueenside, BlackKingside, BlackQueenside} {
+		if c.Has(r) {
+			h ^= polyglotRandom[768+i]
+		}
+	}
+	return h
+}
+
+// enPassantKey returns the key for the en passant file, or 0 if there is
+// no en passant square or no pawn of the side to move beside the pawn that
+// just made a double push.
+func (b *Board) enPassantKey() uint64 {
+	if b.EnPassant == NoSquare {
+		return 0
+	}
+	// the squares a pawn could capture onto the en passant square from are
+	// those an enemy pawn standing on it would attack
+	side := b.SideToMove
+	if pawnAttacks[side.Opponent()][b.EnPassant]&b.pieceBB[side][pieces.Pawn] == 0 {
+		return 0
+	}
+	return polyglotRandom[772+b.EnPassant.File()]
+}
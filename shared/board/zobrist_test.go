@@ -0,0 +1,83 @@
+package board
+
+import (
+	"testing"
+)
+
+// TestPolyglotKeys checks Hash against the example keys in the Polyglot
+// book format specification.
+func TestPolyglotKeys(t *testing.T) {
+	tests := []struct {
+		moves []string
+		key   uint64
+	}{
+		{nil, 0x463b96181691fc9c},
+		{[]string{"e2e4"}, 0x823c9b50fd114196},
+		{[]string{"e2e4", "d7d5"}, 0x0756b94461c50fb0},
+		{[]string{"e2e4", "d7d5", "e4e5"}, 0x662fafb965db29d4},
+		{[]string{"e2e4", "d7d5", "e4e5", "f7f5"}, 0x22a48b5a8e47ff78},
+		{[]string{"e2e4", "d7d5", "e4e5", "f7f5", "e1e2"}, 0x652a607ca3f242c1},
+		{[]string{"e2e4", "d7d5", "e4e5", "f7f5", "e1e2", "e8f7"}, 0x00fdd303c946bdd9},
+		{[]string{"a2a4", "b7b5", "h2h4", "b5b4", "c2c4"}, 0x3c8123ea7b067637},
+		{[]string{"a2a4", "b7b5", "h2h4", "b5b4", "c2c4", "b4c3", "a1a3"}, 0x5c3f9b829b279560},
+	}
+	for _, tt := range tests {
+		b, err := ParseFEN(StartFEN)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, uci := range tt.moves {
+			m, err := ParseUCI(uci)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if b, _, err = b.MovePiece(m); err != nil {
+				t.Fatalf("%v: %s: %v", tt.moves, uci, err)
+			}
+		}
+		if b.Hash() != tt.key {
+			t.Errorf("%v: key %016x, want %016x", tt.moves, b.Hash(), tt.key)
+		}
+		if fromFEN, _ := ParseFEN(b.FEN()); fromFEN.Hash() != tt.key {
+			t.Errorf("%v: key from FEN %016x, want %016x", tt.moves, fromFEN.Hash(), tt.key)
+		}
+	}
+}
+
+// The key MovePiece, Make and Unmake keep up to date move by move is the
+// one computed from scratch, in every position two moves deep from the
+// reference positions.
+func TestIncrementalHash(t *testing.T) {
+	var walk func(b Board, depth int)
+	walk = func(b Board, depth int) {
+		if depth == 0 {
+			return
+		}
+		for _, m := range b.LegalMoves() {
+			next, _, err := b.MovePiece(m)
+			if err != nil {
+				t.Fatalf("%s: %s: %v", b.FEN(), m.UCI(), err)
+			}
+			if next.Hash() != next.computeHash() {
+				t.Fatalf("%s after MovePiece(%s): key %016x, want %016x", b.FEN(), m.UCI(), next.Hash(), next.computeHash())
+			}
+			made := b
+			u := made.Make(m)
+			if made.Hash() != next.Hash() {
+				t.Fatalf("%s after Make(%s): key %016x, want %016x", b.FEN(), m.UCI(), made.Hash(), next.Hash())
+			}
+			made.Unmake(u)
+			if made.Hash() != b.Hash() {
+				t.Fatalf("%s after unmaking %s: key %016x, want %016x", b.FEN(), m.UCI(), made.Hash(), b.Hash())
+			}
+			walk(next, depth-1)
+		}
+	}
+	for _, p := range PerftPositions {
+		b, err := ParseFEN(p.FEN)
+		if err != nil {
+			t.Fatal(err)
+		}
+		walk(b, 2)
+	}
+}
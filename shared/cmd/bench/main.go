@@ -0,0 +1,83 @@
+// Command bench searches a fixed set of positions to a fixed depth and
+// reports the engine's speed and how well it prunes: nodes per second, and
+// the effective branching factor, how many times more nodes the search to
+// depth visits than the search to depth-1.
+//
+//	bench -depth 6
+//	bench -depth 6 -weights tuned.json
+//	bench -depth 8 -threads 8
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/tygermarshall/blunderbuss/shared/board"
+	"github.com/tygermarshall/blunderbuss/shared/engine"
+)
+
+func main() {
+	depth := flag.Int("depth", 6, "search depth in plies")
+	weights := flag.String("weights", "", "JSON file of evaluation weights to use instead of the defaults")
+	threads := flag.Int("threads", 1, "search threads")
+	flag.Parse()
+	if *depth < 2 {
+		fmt.Fprintln(os.Stderr, "depth must be at least 2")
+		os.Exit(2)
+	}
+
+	opts := engine.Options{Threads: *threads}
+	if *weights != "" {
+		w, err := engine.LoadWeights(*weights)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		opts.Evaluator = engine.NewTaperedEvaluator(w)
+	}
+	e := engine.New(opts)
+	var nodes, prevNodes int64
+	var elapsed time.Duration
+	for _, p := range board.PerftPositions {
+		b, err := board.ParseFEN(p.FEN)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", p.Name, err)
+			os.Exit(2)
+		}
+		prev, err := search(e, b, *depth-1)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", p.Name, err)
+			os.Exit(1)
+		}
+		r, err := search(e, b, *depth)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", p.Name, err)
+			os.Exit(1)
+		}
+		fmt.Printf("%-20s %-6s score %6d  nodes %10d  nps %9.0f  ebf %5.2f  %s\n",
+			p.Name, r.Move.UCI(), r.Score, r.Nodes, nps(r.Nodes, r.Time), ebf(r.Nodes, prev.Nodes), r.Time.Round(time.Millisecond))
+		nodes += r.Nodes
+		prevNodes += prev.Nodes
+		elapsed += r.Time
+	}
+	fmt.Printf("\ntotal nodes %d  time %s  nps %.0f  ebf %.2f\n",
+		nodes, elapsed.Round(time.Millisecond), nps(nodes, elapsed), ebf(nodes, prevNodes))
+}
+
+// search searches b to depth from an empty table, as at the start of a
+// game.
+func search(e *engine.Engine, b board.Board, depth int) (engine.Result, error) {
+	e.Clear()
+	return e.Search(context.Background(), b, engine.Limits{Depth: depth})
+}
+
+func nps(nodes int64, d time.Duration) float64 {
+	return float64(nodes) / d.Seconds()
+}
+
+func ebf(nodes, prevNodes int64) float64 {
+	return float64(nodes) / float64(prevNodes)
+}
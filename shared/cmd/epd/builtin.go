@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/tygermarshall/blunderbuss/shared/board"
+	"github.com/tygermarshall/blunderbuss/shared/engine"
+)
+
+// builtinEngine is Blunderbuss's own engine.
+type builtinEngine struct {
+	engine *engine.Engine
+	limits engine.Limits
+}
+
+// newBuiltinEngine returns the built-in engine searching each position to
+// depth if it is positive and for movetime otherwise, on threads threads.
+// It evaluates with the weights in the JSON file at weightsPath, or the
+// defaults if it is empty.
+func newBuiltinEngine(movetime time.Duration, depth, threads int, weightsPath string) (*builtinEngine, error) {
+	opts := engine.Options{Threads: threads}
+	if weightsPath != "" {
+		w, err := engine.LoadWeights(weightsPath)
+		if err != nil {
+			return nil, err
+		}
+		opts.Evaluator = engine.NewTaperedEvaluator(w)
+	}
+	e := &builtinEngine{engine: engine.New(opts), limits: engine.Limits{MoveTime: movetime}}
+	if depth > 0 {
+		e.limits = engine.Limits{Depth: depth}
+	}
+	return e, nil
+}
+
+// BestMove searches b and returns the move the engine chose.
+func (e *builtinEngine) BestMove(b board.Board) (board.Move, error) {
+	// the positions of a suite are unrelated
+	e.engine.Clear()
+	r, err := e.engine.Search(context.Background(), b, e.limits)
+	return r.Move, err
+}
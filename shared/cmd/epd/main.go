@@ -0,0 +1,150 @@
+// Command epd runs an EPD test suite, such as WAC, STS or Bratko-Kopec,
+// against a chess engine and reports which positions it solves. A position
+// is solved when the engine plays one of its bm moves and none of its am
+// moves.
+//
+//	epd -engine stockfish -movetime 1s wac.epd
+//	epd -engine ./engine -depth 8 bk.epd sts1.epd
+//	epd -movetime 5s wac.epd                      the built-in engine
+//	epd -movetime 5s -weights tuned.json wac.epd
+//	epd -movetime 5s -threads 8 wac.epd
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/tygermarshall/blunderbuss/shared/board"
+)
+
+// searcher picks the move to play in a position.
+type searcher interface {
+	BestMove(b board.Board) (board.Move, error)
+}
+
+func main() {
+	enginePath := flag.String("engine", "", "path of a UCI engine to test instead of the built-in engine")
+	movetime := flag.Duration("movetime", time.Second, "time to search each position")
+	depth := flag.Int("depth", 0, "search each position to this depth instead of for -movetime")
+	weights := flag.String("weights", "", "JSON file of evaluation weights for the built-in engine")
+	threads := flag.Int("threads", 1, "search threads for the built-in engine")
+	flag.Parse()
+	if flag.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: epd [-engine <path>] [-movetime 1s | -depth n] suite.epd...")
+		os.Exit(2)
+	}
+
+	var engine searcher
+	if *enginePath == "" {
+		builtin, err := newBuiltinEngine(*movetime, *depth, *threads, *weights)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		engine = builtin
+	} else {
+		uci, err := startUCIEngine(*enginePath, *movetime, *depth)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		defer uci.Close()
+		engine = uci
+	}
+
+	var solved, total int
+	for _, path := range flag.Args() {
+		s, n, err := runSuite(engine, path)
+		solved += s
+		total += n
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+	}
+	fmt.Printf("\nsolved %d of %d\n", solved, total)
+}
+
+// runSuite tests every position of the EPD file at path that has a bm or
+// am opcode, printing a line for each, and returns how many were solved
+// out of how many were tested.
+func runSuite(s searcher, path string) (solved, total int, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		e, err := board.ParseEPD(text)
+		if err != nil {
+			fmt.Printf("%s:%d: %v\n", path, line, err)
+			continue
+		}
+		best, _ := e.BestMoves()
+		avoid, _ := e.AvoidMoves()
+		if len(best) == 0 && len(avoid) == 0 {
+			continue
+		}
+		id := e.ID()
+		if id == "" {
+			id = fmt.Sprintf("%s:%d", path, line)
+		}
+
+		total++
+		m, err := s.BestMove(e.Board)
+		if err != nil {
+			return solved, total, fmt.Errorf("%s: %w", id, err)
+		}
+		pass := (len(best) == 0 || contains(best, m)) && !contains(avoid, m)
+		if pass {
+			solved++
+		}
+		fmt.Printf("%-4s %-20s %s  got %s\n", result(pass), id, expected(e), san(e.Board, m))
+	}
+	return solved, total, sc.Err()
+}
+
+func result(pass bool) string {
+	if pass {
+		return "pass"
+	}
+	return "FAIL"
+}
+
+// expected describes the moves a position asks for, e.g. "bm Qg6 Qh5".
+func expected(e board.EPD) string {
+	var parts []string
+	for _, opcode := range []string{"bm", "am"} {
+		if operands, ok := e.Op(opcode); ok {
+			parts = append(parts, opcode+" "+strings.Join(operands, " "))
+		}
+	}
+	return strings.Join(parts, "; ")
+}
+
+func san(b board.Board, m board.Move) string {
+	s, err := b.SAN(m)
+	if err != nil {
+		return m.UCI()
+	}
+	return s
+}
+
+func contains(moves []board.Move, m board.Move) bool {
+	for _, c := range moves {
+		if c.From == m.From && c.To == m.To && c.Promotion == m.Promotion {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/tygermarshall/blunderbuss/shared/board"
+)
+
+// uciEngine is an external engine process spoken to over the Universal
+// Chess Interface.
+type uciEngine struct {
+	cmd      *exec.Cmd
+	in       io.WriteCloser
+	out      *bufio.Scanner
+	movetime time.Duration
+	depth    int
+}
+
+// startUCIEngine runs the engine at path and waits for it to be ready.
+// Each search runs to depth if it is positive and for movetime otherwise.
+func startUCIEngine(path string, movetime time.Duration, depth int) (*uciEngine, error) {
+	cmd := exec.Command(path)
+	in, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	e := &uciEngine{cmd: cmd, in: in, out: bufio.NewScanner(out), movetime: movetime, depth: depth}
+	if err := e.send("uci"); err != nil {
+		return nil, err
+	}
+	if _, err := e.waitFor("uciok"); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// BestMove searches b and returns the move the engine chose.
+func (e *uciEngine) BestMove(b board.Board) (board.Move, error) {
+	for _, cmd := range []string{"ucinewgame", "isready"} {
+		if err := e.send(cmd); err != nil {
+			return board.Move{}, err
+		}
+	}
+	if _, err := e.waitFor("readyok"); err != nil {
+		return board.Move{}, err
+	}
+	if err := e.send("position fen " + b.FEN()); err != nil {
+		return board.Move{}, err
+	}
+	var err error
+	if e.depth > 0 {
+		err = e.send(fmt.Sprintf("go depth %d", e.depth))
+	} else {
+		err = e.send(fmt.Sprintf("go movetime %d", e.movetime.Milliseconds()))
+	}
+	if err != nil {
+		return board.Move{}, err
+	}
+
+	line, err := e.waitFor("bestmove")
+	if err != nil {
+		return board.Move{}, err
+	}
+	fields := strings.Fields(line)
+	if len(fields) < 2 {
+		return board.Move{}, fmt.Errorf("engine sent %q", line)
+	}
+	m, err := board.ParseUCI(fields[1])
+	if err != nil {
+		return board.Move{}, err
+	}
+	_, applied, err := b.MovePiece(m)
+	if err != nil {
+		return board.Move{}, fmt.Errorf("engine played %s: %w", fields[1], err)
+	}
+	return applied, nil
+}
+
+// Close asks the engine to quit and waits for it to exit.
+func (e *uciEngine) Close() error {
+	e.send("quit")
+	e.in.Close()
+	return e.cmd.Wait()
+}
+
+func (e *uciEngine) send(cmd string) error {
+	_, err := io.WriteString(e.in, cmd+"\n")
+	return err
+}
+
+// waitFor reads engine output up to the first line starting with the
+// given word and returns that line.
+func (e *uciEngine) waitFor(word string) (string, error) {
+	for e.out.Scan() {
+		line := e.out.Text()
+		if line == word || strings.HasPrefix(line, word+" ") {
+			return line, nil
+		}
+	}
+	if err := e.out.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("engine exited while waiting for %s", word)
+}
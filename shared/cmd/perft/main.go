@@ -0,0 +1,93 @@
+// Command perft counts legal move tree nodes to check the move generator.
+//
+//	perft -position kiwipete -depth 3          total node count
+//	perft -fen "<fen>" -depth 3                start from any position
+//	perft -position kiwipete -depth 3 -divide  node count under each move
+//	perft -suite -maxnodes 1000000             check every reference position
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/tygermarshall/blunderbuss/shared/board"
+)
+
+func main() {
+	position := flag.String("position", "start", "name of a reference position")
+	fen := flag.String("fen", "", "FEN of the position to search, overriding -position")
+	depth := flag.Int("depth", 4, "search depth in plies")
+	divide := flag.Bool("divide", false, "print the node count under each root move")
+	suite := flag.Bool("suite", false, "check all reference positions against their known counts")
+	maxNodes := flag.Int64("maxnodes", 5000000, "with -suite, skip depths whose expected count exceeds this")
+	flag.Parse()
+
+	if *suite {
+		if !runSuite(*maxNodes) {
+			os.Exit(1)
+		}
+		return
+	}
+
+	b, ok := board.PerftBoard(*position)
+	if *fen != "" {
+		var err error
+		if b, err = board.ParseFEN(*fen); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+	} else if !ok {
+		fmt.Fprintf(os.Stderr, "unknown position %q\n", *position)
+		os.Exit(2)
+	}
+
+	start := time.Now()
+	if *divide {
+		counts := b.Divide(*depth)
+		moves := make([]board.Move, 0, len(counts))
+		for m := range counts {
+			moves = append(moves, m)
+		}
+		sort.Slice(moves, func(i, j int) bool { return moves[i].UCI() < moves[j].UCI() })
+		var total int64
+		for _, m := range moves {
+			fmt.Printf("%s: %d\n", m.UCI(), counts[m])
+			total += counts[m]
+		}
+		fmt.Printf("\nmoves: %d\nnodes: %d\n", len(moves), total)
+	} else {
+		fmt.Printf("nodes: %d\n", b.Perft(*depth))
+	}
+	fmt.Printf("time: %s\n", time.Since(start))
+}
+
+// runSuite checks every reference position at each depth whose expected
+// count is at most maxNodes and reports whether all of them matched.
+func runSuite(maxNodes int64) bool {
+	passed := true
+	for _, p := range board.PerftPositions {
+		b, ok := board.PerftBoard(p.Name)
+		if !ok {
+			fmt.Printf("FAIL %s: cannot load position\n", p.Name)
+			passed = false
+			continue
+		}
+		for i, want := range p.Nodes {
+			if want > maxNodes {
+				break
+			}
+			start := time.Now()
+			got := b.Perft(i + 1)
+			status := "ok  "
+			if got != want {
+				status = "FAIL"
+				passed = false
+			}
+			fmt.Printf("%s %s depth %d: got %d, want %d (%s)\n", status, p.Name, i+1, got, want, time.Since(start).Round(time.Millisecond))
+		}
+	}
+	return passed
+}
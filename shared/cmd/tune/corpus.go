@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/tygermarshall/blunderbuss/shared/board"
+	"github.com/tygermarshall/blunderbuss/shared/engine"
+	"github.com/tygermarshall/blunderbuss/shared/pgn"
+	"github.com/tygermarshall/blunderbuss/shared/pieces"
+)
+
+// position is a corpus position reduced to what tuning needs: the
+// evaluation's features, the game phase and the result of the game from
+// white's point of view, 1 for a win, 0.5 for a draw and 0 for a loss.
+type position struct {
+	features []feature
+	phase    uint8
+	result   float32
+}
+
+// feature is a compact engine.Feature; a corpus can hold millions.
+type feature struct {
+	param uint16
+	count int16
+}
+
+func newPosition(eval *engine.TaperedEvaluator, b *board.Board, result float32) position {
+	fs, phase := eval.Features(b)
+	p := position{features: make([]feature, len(fs)), phase: uint8(phase), result: result}
+	for i, f := range fs {
+		p.features[i] = feature{param: uint16(f.Param), count: int16(f.Count)}
+	}
+	return p
+}
+
+// results maps game results to scores for white.
+var results = map[string]float32{
+	pgn.ResultWhiteWins: 1,
+	pgn.ResultDraw:      0.5,
+	pgn.ResultBlackWins: 0,
+}
+
+// load appends the labelled positions of the file at path to corpus.
+func load(corpus []position, eval *engine.TaperedEvaluator, path string, skip int) ([]position, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return corpus, err
+	}
+	defer f.Close()
+	if strings.HasSuffix(path, ".epd") {
+		return loadEPD(corpus, eval, f, path)
+	}
+	return loadPGN(corpus, eval, f, path, skip)
+}
+
+// loadEPD reads positions labelled with their result in the c9 opcode.
+func loadEPD(corpus []position, eval *engine.TaperedEvaluator, r io.Reader, path string) ([]position, error) {
+	sc := bufio.NewScanner(r)
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		e, err := board.ParseEPD(text)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s:%d: %v\n", path, line, err)
+			continue
+		}
+		result, ok := results[e.Comment(9)]
+		if !ok {
+			continue
+		}
+		corpus = append(corpus, newPosition(eval, &e.Board, result))
+	}
+	return corpus, sc.Err()
+}
+
+// loadPGN reads the positions of the mainline of every finished game,
+// skipping games it cannot read. The evaluation is meant for quiet
+// positions, so it leaves out the first skip plies, which are mostly
+// opening book, and positions in check or straight after a capture or
+// promotion, which are likely mid-exchange.
+func loadPGN(corpus []position, eval *engine.TaperedEvaluator, r io.Reader, path string, skip int) ([]position, error) {
+	pr := pgn.NewReader(r)
+	for {
+		g, err := pr.Next()
+		if errors.Is(err, io.EOF) {
+			return corpus, nil
+		}
+		var readErr *fs.PathError
+		if errors.As(err, &readErr) {
+			return corpus, err
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: skipping game: %v\n", path, err)
+			continue
+		}
+		result, ok := results[g.Result]
+		if !ok {
+			continue
+		}
+		ply := 0
+		for n := g.Root.Next(); n != nil; n = n.Next() {
+			ply++
+			if ply <= skip || n.Move.IsCapture() || n.Move.Promotion != pieces.Empty || n.Board.InCheck() {
+				continue
+			}
+			corpus = append(corpus, newPosition(eval, &n.Board, result))
+		}
+	}
+}
@@ -0,0 +1,97 @@
+// Command tune fits the evaluation weights to the results of real games,
+// Texel style. Each position of a corpus is labelled with the result of
+// its game, and tune looks for the weights whose evaluations, squashed
+// through a sigmoid into an expected score, predict those results with
+// the least squared error. The tuned weights are written out as JSON for
+// engine.LoadWeights and the -weights flags of the other commands.
+//
+//	tune -out tuned.json games.pgn
+//	tune -out tuned.json -weights tuned.json -epochs 200 quiet-labeled.epd
+//
+// Files ending in .epd are read as EPD, with the result in the c9 opcode
+// as "1-0", "0-1" or "1/2-1/2". Anything else is read as PGN.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/tygermarshall/blunderbuss/shared/engine"
+)
+
+func main() {
+	out := flag.String("out", "", "file to write the tuned weights to")
+	weights := flag.String("weights", "", "JSON file of weights to start from instead of the defaults")
+	epochs := flag.Int("epochs", 500, "passes over the corpus")
+	rate := flag.Float64("rate", 1, "learning rate in centipawns")
+	k := flag.Float64("k", 0, "sigmoid scale; 0 fits it to the starting weights")
+	skip := flag.Int("skip", 8, "PGN plies to skip at the start of each game")
+	threads := flag.Int("threads", runtime.NumCPU(), "goroutines to spread the work over")
+	flag.Parse()
+	if *out == "" || flag.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: tune -out tuned.json [-weights start.json] games.pgn positions.epd...")
+		os.Exit(2)
+	}
+
+	start := engine.DefaultWeights()
+	if *weights != "" {
+		var err error
+		if start, err = engine.LoadWeights(*weights); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+	}
+
+	eval := engine.NewTaperedEvaluator(start)
+	var corpus []position
+	for _, path := range flag.Args() {
+		var err error
+		if corpus, err = load(corpus, eval, path, *skip); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+	}
+	if len(corpus) == 0 {
+		fmt.Fprintln(os.Stderr, "no labelled positions found")
+		os.Exit(1)
+	}
+	fmt.Printf("%d positions\n", len(corpus))
+
+	t := newTuner(corpus, start, *threads)
+	if *k == 0 {
+		*k = t.fitK()
+	}
+	t.k = *k
+	fmt.Printf("k %.4f  error %.6f\n", t.k, t.error())
+
+	began := time.Now()
+	for epoch := 1; epoch <= *epochs; epoch++ {
+		e := t.step(*rate)
+		if epoch%10 == 0 || epoch == *epochs {
+			fmt.Printf("epoch %d  error %.6f  %s\n", epoch, e, time.Since(began).Round(time.Second))
+		}
+		// save as we go, so a long run can be stopped at any point
+		if epoch%50 == 0 || epoch == *epochs {
+			if err := save(*out, t.weights()); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+		}
+	}
+	fmt.Printf("wrote %s\n", *out)
+}
+
+func save(path string, w engine.Weights) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := w.Write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
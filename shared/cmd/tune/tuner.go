@@ -0,0 +1,201 @@
+package main
+
+import (
+	"math"
+	"sync"
+
+	"github.com/tygermarshall/blunderbuss/shared/engine"
+)
+
+// Adam's decay rates for its running averages of the gradient and of its
+// square, as recommended by its authors.
+const (
+	beta1   = 0.9
+	beta2   = 0.999
+	epsilon = 1e-8
+)
+
+// tuner minimizes the mean squared error between the results of the corpus
+// positions and their evaluations squashed by a sigmoid. The evaluation is
+// linear in the weights given a position's features, so both the error
+// and its gradient come from the features alone, without evaluating
+// positions again. It steps down the gradient of the whole corpus with
+// Adam, which adapts the step size of each weight to how noisy its
+// gradient is.
+type tuner struct {
+	corpus  []position
+	start   engine.Weights
+	threads int
+	// k scales evaluations before the sigmoid, so that the weights stay in
+	// centipawns.
+	k float64
+
+	// params are the weights as a vector, in engine.Weights.Params order,
+	// with the middlegame and endgame values of each pair side by side.
+	params [][2]float64
+	// m and v are Adam's running averages of the gradient and of its
+	// square, and steps how many updates it has made.
+	m, v  [][2]float64
+	steps int
+}
+
+func newTuner(corpus []position, start engine.Weights, threads int) *tuner {
+	ps := start.Params()
+	t := &tuner{
+		corpus:  corpus,
+		start:   start,
+		threads: max(threads, 1),
+		params:  make([][2]float64, len(ps)),
+		m:       make([][2]float64, len(ps)),
+		v:       make([][2]float64, len(ps)),
+	}
+	for i, p := range ps {
+		t.params[i] = [2]float64{float64(p[0]), float64(p[1])}
+	}
+	return t
+}
+
+// evaluate returns p's evaluation from white's point of view under the
+// current parameters.
+func (t *tuner) evaluate(p *position) float64 {
+	var mg, eg float64
+	for _, f := range p.features {
+		mg += t.params[f.param][0] * float64(f.count)
+		eg += t.params[f.param][1] * float64(f.count)
+	}
+	phase := float64(p.phase)
+	return (mg*phase + eg*(engine.MaxPhase-phase)) / engine.MaxPhase
+}
+
+// sigmoid maps an evaluation in centipawns to an expected score for white
+// between 0 and 1.
+func sigmoid(k, eval float64) float64 {
+	return 1 / (1 + math.Pow(10, -k*eval/400))
+}
+
+// error returns the mean squared error of the current parameters.
+func (t *tuner) error() float64 {
+	return t.errorAt(t.k)
+}
+
+func (t *tuner) errorAt(k float64) float64 {
+	sums := make([]float64, t.threads)
+	t.parallel(func(worker int, corpus []position) {
+		sum := 0.0
+		for i := range corpus {
+			d := float64(corpus[i].result) - sigmoid(k, t.evaluate(&corpus[i]))
+			sum += d * d
+		}
+		sums[worker] = sum
+	})
+	total := 0.0
+	for _, sum := range sums {
+		total += sum
+	}
+	return total / float64(len(t.corpus))
+}
+
+// gradient returns the gradient of the mean squared error with respect to
+// the parameters, and the error itself.
+func (t *tuner) gradient() ([][2]float64, float64) {
+	grads := make([][][2]float64, t.threads)
+	sums := make([]float64, t.threads)
+	t.parallel(func(worker int, corpus []position) {
+		grad := make([][2]float64, len(t.params))
+		sum := 0.0
+		for i := range corpus {
+			p := &corpus[i]
+			s := sigmoid(t.k, t.evaluate(p))
+			d := float64(p.result) - s
+			sum += d * d
+			// the derivative of d² by the evaluation, split between the
+			// middlegame and endgame values by the phase
+			slope := -2 * d * s * (1 - s) * t.k * math.Ln10 / 400
+			mg := slope * float64(p.phase) / engine.MaxPhase
+			eg := slope * float64(engine.MaxPhase-int(p.phase)) / engine.MaxPhase
+			for _, f := range p.features {
+				grad[f.param][0] += mg * float64(f.count)
+				grad[f.param][1] += eg * float64(f.count)
+			}
+		}
+		grads[worker], sums[worker] = grad, sum
+	})
+
+	n := float64(len(t.corpus))
+	total := make([][2]float64, len(t.params))
+	err := 0.0
+	for w, grad := range grads {
+		for i := range grad {
+			total[i][0] += grad[i][0] / n
+			total[i][1] += grad[i][1] / n
+		}
+		err += sums[w]
+	}
+	return total, err / n
+}
+
+// step moves the parameters one Adam step of about rate centipawns down the
+// gradient of the whole corpus and returns the error before the step.
+func (t *tuner) step(rate float64) float64 {
+	grad, err := t.gradient()
+	t.steps++
+	c1 := 1 - math.Pow(beta1, float64(t.steps))
+	c2 := 1 - math.Pow(beta2, float64(t.steps))
+	for i := range t.params {
+		for j := range t.params[i] {
+			g := grad[i][j]
+			t.m[i][j] = beta1*t.m[i][j] + (1-beta1)*g
+			t.v[i][j] = beta2*t.v[i][j] + (1-beta2)*g*g
+			t.params[i][j] -= rate * (t.m[i][j] / c1) / (math.Sqrt(t.v[i][j]/c2) + epsilon)
+		}
+	}
+	return err
+}
+
+// fitK returns the sigmoid scale that best fits the current parameters to
+// the corpus, found by golden section search.
+func (t *tuner) fitK() float64 {
+	lo, hi := 0.05, 5.0
+	ratio := (math.Sqrt(5) - 1) / 2
+	a, b := hi-ratio*(hi-lo), lo+ratio*(hi-lo)
+	ea, eb := t.errorAt(a), t.errorAt(b)
+	for hi-lo > 1e-4 {
+		if ea < eb {
+			hi, b, eb = b, a, ea
+			a = hi - ratio*(hi-lo)
+			ea = t.errorAt(a)
+		} else {
+			lo, a, ea = a, b, eb
+			b = lo + ratio*(hi-lo)
+			eb = t.errorAt(b)
+		}
+	}
+	return (lo + hi) / 2
+}
+
+// weights returns the current parameters rounded to whole centipawns.
+func (t *tuner) weights() engine.Weights {
+	w := t.start
+	for i, p := range w.Params() {
+		p[0] = int(math.Round(t.params[i][0]))
+		p[1] = int(math.Round(t.params[i][1]))
+	}
+	return w
+}
+
+// parallel splits the corpus into one chunk per thread and calls fn on
+// each chunk in its own goroutine, numbering them from 0.
+func (t *tuner) parallel(fn func(worker int, corpus []position)) {
+	size := (len(t.corpus) + t.threads - 1) / t.threads
+	var wg sync.WaitGroup
+	for w := 0; w < t.threads; w++ {
+		lo := min(w*size, len(t.corpus))
+		hi := min(lo+size, len(t.corpus))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fn(w, t.corpus[lo:hi])
+		}()
+	}
+	wg.Wait()
+}
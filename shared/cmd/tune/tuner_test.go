@@ -0,0 +1,85 @@
+package main
+
+import (
+	"math"
+	"testing"
+
+	"github.com/tygermarshall/blunderbuss/shared/board"
+	"github.com/tygermarshall/blunderbuss/shared/engine"
+)
+
+// testCorpus returns the perft positions and every position one move on
+// from them, labelled with results that cycle through loss, draw and win.
+func testCorpus(t *testing.T) []position {
+	t.Helper()
+	eval := engine.NewTaperedEvaluator(engine.DefaultWeights())
+	var corpus []position
+	add := func(b *board.Board) {
+		result := float32(len(corpus)%3) / 2
+		corpus = append(corpus, newPosition(eval, b, result))
+	}
+	for _, p := range board.PerftPositions {
+		b, err := board.ParseFEN(p.FEN)
+		if err != nil {
+			t.Fatal(err)
+		}
+		add(&b)
+		for _, m := range b.LegalMoves() {
+			next, _, err := b.MovePiece(m)
+			if err != nil {
+				t.Fatal(err)
+			}
+			add(&next)
+		}
+	}
+	return corpus
+}
+
+// The gradient agrees with the slope of the error measured by nudging each
+// parameter either way.
+func TestGradient(t *testing.T) {
+	tr := newTuner(testCorpus(t), engine.DefaultWeights(), 3)
+	tr.k = 1.2
+	grad, err := tr.gradient()
+	if want := tr.error(); math.Abs(err-want) > 1e-12 {
+		t.Errorf("gradient gives the error as %g, want %g", err, want)
+	}
+
+	const h = 0.5
+	checked := 0
+	for i := range tr.params {
+		for j := range tr.params[i] {
+			saved := tr.params[i][j]
+			tr.params[i][j] = saved + h
+			up := tr.error()
+			tr.params[i][j] = saved - h
+			down := tr.error()
+			tr.params[i][j] = saved
+
+			slope := (up - down) / (2 * h)
+			if slope != 0 {
+				checked++
+			}
+			if math.Abs(grad[i][j]-slope) > 1e-4*math.Abs(slope)+1e-12 {
+				t.Errorf("param %d[%d]: gradient %g, measured %g", i, j, grad[i][j], slope)
+			}
+		}
+	}
+	if checked < 100 {
+		t.Errorf("only %d parameters move the error", checked)
+	}
+}
+
+// fitK finds the scale the corpus results were made with.
+func TestFitK(t *testing.T) {
+	corpus := testCorpus(t)
+	tr := newTuner(corpus, engine.DefaultWeights(), 2)
+	for _, k := range []float64{0.4, 1.13, 2.5} {
+		for i := range corpus {
+			corpus[i].result = float32(sigmoid(k, tr.evaluate(&corpus[i])))
+		}
+		if got := tr.fitK(); math.Abs(got-k) > 1e-3 {
+			t.Errorf("fitK() = %.4f, want %.4f", got, k)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+// Package engine searches chess positions for the best move, for playing
+// against the computer, hints and analysis.
+//
+// An Engine runs a negamax alpha-beta search with iterative deepening: it
+// searches one ply deep, then two, and so on, until it reaches the depth
+// limit, runs out of time or its context is done, and returns the result of
+// the deepest search it finished. At the end of each line a quiescence
+// search plays out captures and checks so that no position is scored in
+// the middle of an exchange. A transposition table shared between
+// iterations and searches, and killer and history move ordering, keep the
+// tree small.
+//
+// An Engine can search with several threads, Lazy SMP style: each thread
+// searches the same root on its own, and they share the transposition
+// table, so each thread's results cut short the others' searches. The
+// helper threads start at staggered depths and order moves differently
+// as their histories diverge, so between them they fill the table with
+// more of the tree than one thread would.
+package engine
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/tygermarshall/blunderbuss/shared/board"
+)
+
+// Scores are in centipawns from the point of view of the side to move. A
+// side that mates in n plies scores MateScore-n, and one that is mated in
+// n plies scores -(MateScore-n).
+const (
+	MateScore = 32000
+	infinity  = MateScore + 1
+)
+
+// MaxDepth is the deepest a search goes.
+const MaxDepth = 64
+
+// ErrNoMoves is returned when asked to search a position in which the
+// side to move has no legal moves.
+var ErrNoMoves = errors.New("engine: no legal moves")
+
+// Limits bound a search. A zero field sets no limit; a search with neither
+// limit runs to MaxDepth unless its context is done first.
+type Limits struct {
+	// Depth is the deepest iteration to search, in plies.
+	Depth int
+	// MoveTime is how long the search may take.
+	MoveTime time.Duration
+	// History holds the board.Board.Hash of each position played in the
+	// game before the one searched, oldest first. A line that returns to
+	// one of them is scored as a draw, as is one that repeats a position
+	// within the search.
+	History []uint64
+}
+
+// Result is the outcome of a search.
+type Result struct {
+	// Move is the best move found.
+	Move board.Move
+	// Score is the value of Move for the side to move.
+	Score int
+	// Depth is the depth of the deepest finished iteration.
+	Depth int
+	// PV is the principal variation, the line both sides are expected to
+	// play, starting with Move.
+	PV []board.Move
+	// Nodes counts the positions searched.
+	Nodes int64
+	// Time is how long the search took.
+	Time time.Duration
+}
+
+// DefaultHashMB is the transposition table size used when Options leaves
+// it unset.
+const DefaultHashMB = 16
+
+// Options configure an Engine.
+type Options struct {
+	// HashMB is the size of the transposition table in megabytes.
+	HashMB int
+	// Evaluator scores the positions at the leaves of the search. It
+	// defaults to a TaperedEvaluator with the DefaultWeights.
+	Evaluator Evaluator
+	// Threads is how many goroutines each search runs on. With the
+	// default of one, a search from a cleared table gives the same result
+	// every time; with more, the result depends on how the threads are
+	// scheduled.
+	Threads int
+}
+
+// An Engine searches positions. It keeps a transposition table of what it
+// has learned between searches, so searching the positions of one game in
+// turn gets quicker. It is safe for concurrent use.
+type Engine struct {
+	tt      *table
+	eval    Evaluator
+	threads int
+}
+
+// New returns an engine configured by opts.
+func New(opts Options) *Engine {
+	if opts.HashMB <= 0 {
+		opts.HashMB = DefaultHashMB
+	}
+	if opts.Evaluator == nil {
+		opts.Evaluator = NewTaperedEvaluator(DefaultWeights())
+	}
+	if opts.Threads <= 0 {
+		opts.Threads = 1
+	}
+	return &Engine{tt: newTable(opts.HashMB), eval: opts.Evaluator, threads: opts.Threads}
+}
+
+// Clear forgets everything learned in earlier searches, as before a new
+// game.
+func (e *Engine) Clear() {
+	e.tt.clear()
+}
+
+// Search looks for the best move for the side to move in b within limits.
+// Cancelling ctx stops the search, which then returns the result of the
+// deepest finished iteration. If the search stops before its first
+// iteration finishes, the result has the first legal move found and a
+// Depth of 0, and the error is ctx's error if ctx is done.
+//
+// With more than one thread, the result is that of the main thread, and
+// Nodes counts the positions searched by all of them. Search returns once
+// every helper has stopped.
+func (e *Engine) Search(ctx context.Context, b board.Board, limits Limits) (Result, error) {
+	start := time.Now()
+	var deadline time.Time
+	if limits.MoveTime > 0 {
+		deadline = start.Add(limits.MoveTime)
+	}
+	depth := limits.Depth
+	if depth <= 0 || depth > MaxDepth {
+		depth = MaxDepth
+	}
+
+	rootMoves := b.AppendLegalMoves(nil)
+	if len(rootMoves) == 0 {
+		return Result{}, ErrNoMoves
+	}
+
+	// the helpers stop when the main thread does, or when ctx is done
+	helperCtx, stopHelpers := context.WithCancel(ctx)
+	defer stopHelpers()
+	helpers := make([]*search, e.threads-1)
+	var wg sync.WaitGroup
+	for i := range helpers {
+		h := newSearch(helperCtx, b, limits.History, e.tt, e.eval)
+		h.deadline = deadline
+		helpers[i] = h
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// every other helper starts a ply deeper, so that the
+			// threads are not all on the same iteration
+			var r Result
+			h.deepen(1+(i+1)%2, depth, &r)
+		}()
+	}
+
+	s := newSearch(ctx, b, limits.History, e.tt, e.eval)
+	s.deadline = deadline
+	r := Result{Move: rootMoves[0]}
+	s.deepen(1, depth, &r)
+	stopHelpers()
+	wg.Wait()
+
+	r.Nodes = s.nodes
+	for _, h := range helpers {
+		r.Nodes += h.nodes
+	}
+	r.Time = time.Since(start)
+	if r.Depth == 0 {
+		return r, ctx.Err()
+	}
+	return r, nil
+}
@@ -0,0 +1,107 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/tygermarshall/blunderbuss/shared/board"
+)
+
+// searchPositions are the reference positions searched by the engine
+// tests: the start, a middlegame full of tactics and an endgame.
+var searchPositions = []string{
+	board.StartFEN,
+	"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+	"8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1",
+}
+
+// isLegal reports whether m is one of the legal moves in b.
+func isLegal(b board.Board, m board.Move) bool {
+	return slices.ContainsFunc(b.LegalMoves(), func(l board.Move) bool {
+		return l.From == m.From && l.To == m.To && l.Promotion == m.Promotion
+	})
+}
+
+func parseFEN(t *testing.T, fen string) board.Board {
+	t.Helper()
+	b, err := board.ParseFEN(fen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// With one thread, a search from a cleared table depends on nothing but
+// the position and the limits.
+func TestSearchIsDeterministic(t *testing.T) {
+	e := New(Options{HashMB: 4})
+	for _, fen := range searchPositions {
+		b := parseFEN(t, fen)
+		var first Result
+		for run := 0; run < 3; run++ {
+			e.Clear()
+			r, err := e.Search(context.Background(), b, Limits{Depth: 5})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if run == 0 {
+				first = r
+				continue
+			}
+			if r.Move != first.Move || r.Score != first.Score || r.Nodes != first.Nodes || !slices.Equal(r.PV, first.PV) {
+				t.Errorf("%s: run %d found %s scoring %d in %d nodes, run 0 %s scoring %d in %d nodes",
+					fen, run, r.Move.UCI(), r.Score, r.Nodes, first.Move.UCI(), first.Score, first.Nodes)
+			}
+		}
+	}
+}
+
+// TestSearchThreads searches with several threads sharing the table, and is
+// meant to be run with -race as well.
+func TestSearchThreads(t *testing.T) {
+	e := New(Options{HashMB: 4, Threads: 4})
+	for _, fen := range searchPositions {
+		b := parseFEN(t, fen)
+		r, err := e.Search(context.Background(), b, Limits{Depth: 5})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !isLegal(b, r.Move) || r.Depth != 5 {
+			t.Errorf("%s: got %s at depth %d, want a legal move at depth 5", fen, r.Move.UCI(), r.Depth)
+		}
+		next := b
+		for i, m := range r.PV {
+			if !isLegal(next, m) {
+				t.Fatalf("%s: PV move %d %s is illegal", fen, i, m.UCI())
+			}
+			next.Make(m)
+		}
+	}
+}
+
+func TestSearchCancelled(t *testing.T) {
+	e := New(Options{HashMB: 4, Threads: 2})
+	for _, fen := range searchPositions {
+		b := parseFEN(t, fen)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		r, err := e.Search(ctx, b, Limits{})
+		if !errors.Is(err, context.Canceled) || r.Depth != 0 || !isLegal(b, r.Move) {
+			t.Errorf("%s, cancelled before: got %s at depth %d, %v; want a legal move at depth 0 and context.Canceled",
+				fen, r.Move.UCI(), r.Depth, err)
+		}
+
+		// cancelled part way through, after some iterations
+		ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+		r, err = e.Search(ctx, b, Limits{})
+		cancel()
+		if err != nil || r.Depth == 0 || r.Depth == MaxDepth || !isLegal(b, r.Move) {
+			t.Errorf("%s, cancelled during: got %s at depth %d, %v; want a legal move from a finished iteration",
+				fen, r.Move.UCI(), r.Depth, err)
+		}
+	}
+}
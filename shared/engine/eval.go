@@ -0,0 +1,242 @@
+package engine
+
+import (
+	"github.com/tygermarshall/blunderbuss/shared/board"
+	"github.com/tygermarshall/blunderbuss/shared/pieces"
+)
+
+// An Evaluator scores positions at the leaves of the search.
+type Evaluator interface {
+	// Evaluate scores b in centipawns from the point of view of the side
+	// to move. It must not modify b, and it must be safe to call from
+	// several goroutines at once.
+	Evaluate(b *board.Board) int
+}
+
+// TaperedEvaluator scores a position twice, once as a middlegame and once
+// as an endgame, and blends the two by how much material is left. It
+// counts material, piece-square tables, pawn structure, mobility and king
+// safety, weighted by its Weights.
+type TaperedEvaluator struct {
+	w Weights
+}
+
+// NewTaperedEvaluator returns an evaluator using w.
+func NewTaperedEvaluator(w Weights) *TaperedEvaluator {
+	return &TaperedEvaluator{w: w}
+}
+
+// Weights returns the weights e evaluates with.
+func (e *TaperedEvaluator) Weights() Weights {
+	return e.w
+}
+
+// MaxPhase is the game phase with all the pieces on the board, a pure
+// middlegame. The phase falls as pieces come off, down to 0 with only
+// kings and pawns, a pure endgame.
+const MaxPhase = 24
+
+var phaseWeights = [...]int{pieces.Knight: 1, pieces.Bishop: 1, pieces.Rook: 2, pieces.Queen: 4}
+
+func gamePhase(b *board.Board) int {
+	phase := 0
+	for pt := pieces.Knight; pt <= pieces.Queen; pt++ {
+		n := b.Pieces(pt, pieces.White).Count() + b.Pieces(pt, pieces.Black).Count()
+		phase += n * phaseWeights[pt]
+	}
+	return min(phase, MaxPhase)
+}
+
+// Evaluate implements Evaluator.
+func (e *TaperedEvaluator) Evaluate(b *board.Board) int {
+	var a accumulator
+	e.evaluate(b, &a)
+	phase := gamePhase(b)
+	score := (a.mg*phase + a.eg*(MaxPhase-phase)) / MaxPhase
+	if b.SideToMove == pieces.Black {
+		return -score
+	}
+	return score
+}
+
+// accumulator sums weighted terms for white. If trace is set, it also
+// counts how many times each weight is added.
+type accumulator struct {
+	mg, eg int
+	trace  map[*Pair]int
+}
+
+// add counts the term weighted by p n times.
+func (a *accumulator) add(p *Pair, n int) {
+	a.mg += p[0] * n
+	a.eg += p[1] * n
+	if a.trace != nil {
+		a.trace[p] += n
+	}
+}
+
+// Feature is how many times one weight counts in an evaluation, for white
+// and against black. Param indexes Weights.Params.
+type Feature struct {
+	Param int
+	Count int
+}
+
+// Features breaks the evaluation of b down into how many times each of
+// e's weights counts towards white's score, and returns them with the game
+// phase. From white's point of view Evaluate is the sum of Count times the
+// weight over the features, middlegame and endgame blended by phase out of
+// MaxPhase. Tuners use this to score many weightings of the same position
+// without evaluating it again.
+func (e *TaperedEvaluator) Features(b *board.Board) ([]Feature, int) {
+	a := accumulator{trace: make(map[*Pair]int)}
+	e.evaluate(b, &a)
+	var features []Feature
+	for i, p := range e.w.Params() {
+		if n := a.trace[p]; n != 0 {
+			features = append(features, Feature{Param: i, Count: n})
+		}
+	}
+	return features, gamePhase(b)
+}
+
+func (e *TaperedEvaluator) evaluate(b *board.Board, a *accumulator) {
+	w := &e.w
+	occupied := b.Occupied()
+	for _, team := range [2]pieces.Team{pieces.White, pieces.Black} {
+		sign := 1
+		if team == pieces.Black {
+			sign = -1
+		}
+		them := team.Opponent()
+		own := b.TeamPieces(team)
+
+		for pt := pieces.Pawn; pt <= pieces.King; pt++ {
+			for bb := b.Pieces(pt, team); bb != 0; {
+				sq := bb.Pop()
+				if pt != pieces.King {
+					a.add(&w.Material[pt], sign)
+				}
+				a.add(&w.PST[pt][pstIndex(team, sq)], sign)
+			}
+		}
+		if b.Pieces(pieces.Bishop, team).Count() >= 2 {
+			a.add(&w.BishopPair, sign)
+		}
+		e.pawnStructure(b, team, sign, a)
+
+		var enemyPawnAttacks board.Bitboard
+		for bb := b.Pieces(pieces.Pawn, them); bb != 0; {
+			enemyPawnAttacks |= board.PawnAttacks(them, bb.Pop())
+		}
+		var kingZone board.Bitboard
+		if kings := b.Pieces(pieces.King, them); kings != 0 {
+			kingZone = board.KingAttacks(kings.First())
+		}
+		kingAttacks := 0
+		for pt := pieces.Knight; pt <= pieces.Queen; pt++ {
+			for bb := b.Pieces(pt, team); bb != 0; {
+				sq := bb.Pop()
+				var attacks board.Bitboard
+				switch pt {
+				case pieces.Knight:
+					attacks = board.KnightAttacks(sq)
+				case pieces.Bishop:
+					attacks = board.BishopAttacks(sq, occupied)
+				case pieces.Rook:
+					attacks = board.RookAttacks(sq, occupied)
+				case pieces.Queen:
+					attacks = board.QueenAttacks(sq, occupied)
+				}
+				a.add(&w.Mobility[pt-pieces.Knight], sign*(attacks&^own&^enemyPawnAttacks).Count())
+				kingAttacks += (attacks & kingZone).Count()
+			}
+		}
+		// attacks on the enemy king count against the enemy
+		a.add(&w.KingAttack, -sign*kingAttacks)
+
+		if kings := b.Pieces(pieces.King, team); kings != 0 {
+			shield := kingShield[team][kings.First()] & b.Pieces(pieces.Pawn, team)
+			a.add(&w.KingShield, sign*shield.Count())
+		}
+	}
+}
+
+func (e *TaperedEvaluator) pawnStructure(b *board.Board, team pieces.Team, sign int, a *accumulator) {
+	w := &e.w
+	pawns := b.Pieces(pieces.Pawn, team)
+	enemyPawns := b.Pieces(pieces.Pawn, team.Opponent())
+	for file := 0; file < 8; file++ {
+		if n := (pawns & board.FileBB(file)).Count(); n > 1 {
+			a.add(&w.DoubledPawn, sign*(n-1))
+		}
+	}
+	for bb := pawns; bb != 0; {
+		sq := bb.Pop()
+		if pawns&neighbourFiles[sq.File()] == 0 {
+			a.add(&w.IsolatedPawn, sign)
+		}
+		if enemyPawns&passedSpan[team][sq] == 0 {
+			a.add(&w.PassedPawn[relativeRank(team, sq)], sign)
+		}
+	}
+}
+
+// pstIndex returns the index into a piece-square table of sq for team.
+// The tables are laid out like a diagram from white's side, rank 8 first,
+// and black's pieces read them mirrored.
+func pstIndex(team pieces.Team, sq board.Square) int {
+	if team == pieces.White {
+		return int(sq) ^ 56
+	}
+	return int(sq)
+}
+
+// relativeRank returns sq's rank counted from team's side of the board.
+func relativeRank(team pieces.Team, sq board.Square) int {
+	if team == pieces.White {
+		return sq.Rank()
+	}
+	return 7 - sq.Rank()
+}
+
+var (
+	// neighbourFiles holds the files either side of each file.
+	neighbourFiles [8]board.Bitboard
+	// passedSpan holds, for a pawn of each team on each square, the
+	// squares ahead of it on its own and neighbouring files. The pawn is
+	// passed if no enemy pawn stands in its span.
+	passedSpan [2][64]board.Bitboard
+	// kingShield holds, for a king of each team on each square, the
+	// squares on the two ranks in front of it on its own and neighbouring
+	// files.
+	kingShield [2][64]board.Bitboard
+)
+
+func init() {
+	for file := 0; file < 8; file++ {
+		if file > 0 {
+			neighbourFiles[file] |= board.FileBB(file - 1)
+		}
+		if file < 7 {
+			neighbourFiles[file] |= board.FileBB(file + 1)
+		}
+	}
+	for sq := board.A1; sq <= board.H8; sq++ {
+		files := neighbourFiles[sq.File()] | board.FileBB(sq.File())
+		for rank := 0; rank < 8; rank++ {
+			if rank > sq.Rank() {
+				passedSpan[pieces.White][sq] |= files & board.RankBB(rank)
+			}
+			if rank < sq.Rank() {
+				passedSpan[pieces.Black][sq] |= files & board.RankBB(rank)
+			}
+			if d := rank - sq.Rank(); d == 1 || d == 2 {
+				kingShield[pieces.White][sq] |= files & board.RankBB(rank)
+			}
+			if d := sq.Rank() - rank; d == 1 || d == 2 {
+				kingShield[pieces.Black][sq] |= files & board.RankBB(rank)
+			}
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package engine
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tygermarshall/blunderbuss/shared/board"
+)
+
+// mirrorFEN returns the position with the board flipped top to bottom and
+// the colours swapped: the same position with the sides' roles reversed.
+func mirrorFEN(t *testing.T, fen string) string {
+	t.Helper()
+	fields := strings.Fields(fen)
+	if len(fields) != 6 {
+		t.Fatalf("%s: want 6 fields", fen)
+	}
+	swapCase := func(s string) string {
+		return strings.Map(func(r rune) rune {
+			switch {
+			case 'a' <= r && r <= 'z':
+				return r - 'a' + 'A'
+			case 'A' <= r && r <= 'Z':
+				return r - 'A' + 'a'
+			}
+			return r
+		}, s)
+	}
+
+	ranks := strings.Split(fields[0], "/")
+	for i, j := 0, len(ranks)-1; i < j; i, j = i+1, j-1 {
+		ranks[i], ranks[j] = ranks[j], ranks[i]
+	}
+	fields[0] = swapCase(strings.Join(ranks, "/"))
+	if fields[1] == "w" {
+		fields[1] = "b"
+	} else {
+		fields[1] = "w"
+	}
+	if fields[2] != "-" {
+		// swapped, then put back in the usual order
+		var castling strings.Builder
+		for _, r := range "KQkq" {
+			if strings.Contains(fields[2], swapCase(string(r))) {
+				castling.WriteRune(r)
+			}
+		}
+		fields[2] = castling.String()
+	}
+	if ep := fields[3]; ep != "-" {
+		fields[3] = ep[:1] + string('1'+'8'-ep[1])
+	}
+	return strings.Join(fields, " ")
+}
+
+// The evaluation is colour blind: the mirrored position scores the same
+// for the side to move, which there is the other colour.
+func TestEvaluateMirrored(t *testing.T) {
+	eval := NewTaperedEvaluator(DefaultWeights())
+	fens := []string{
+		"rnbqkb1r/pp3ppp/4pn2/2pp4/3P4/2P1PN2/PP1N1PPP/R1BQKB1R b KQkq - 0 5",
+		"r1bq1rk1/ppp2ppp/2np1n2/2b1p3/2B1P3/2NP1N2/PPP2PPP/R1BQ1RK1 w - - 0 7",
+		"8/5pk1/6p1/3P4/1p6/8/5PPP/6K1 w - - 0 40",
+		"rnbqkbnr/ppp1pppp/8/3pP3/8/8/PPPP1PPP/RNBQKBNR w Kq d6 0 3",
+	}
+	for _, p := range board.PerftPositions {
+		fens = append(fens, p.FEN)
+	}
+	for _, fen := range fens {
+		mirrored := mirrorFEN(t, fen)
+		b, err := board.ParseFEN(fen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m, err := board.ParseFEN(mirrored)
+		if err != nil {
+			t.Fatalf("%s: mirrored as %s: %v", fen, mirrored, err)
+		}
+		if got, want := eval.Evaluate(&m), eval.Evaluate(&b); got != want {
+			t.Errorf("%s scores %d, mirrored %s scores %d", fen, want, mirrored, got)
+		}
+	}
+}
+
+// Weights written out and loaded back are the weights written, and write
+// out the same again.
+func TestWeightsRoundTrip(t *testing.T) {
+	// every term set, and set apart from its default, so that a term
+	// Write drops or ReadWeights ignores is noticed
+	var w Weights
+	for i, p := range w.Params() {
+		*p = Pair{i + 1, -(i + 1) * 3}
+	}
+
+	var first bytes.Buffer
+	if err := w.Write(&first); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "weights.json")
+	if err := os.WriteFile(path, first.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadWeights(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded != w {
+		t.Errorf("loaded %+v, want %+v", loaded, w)
+	}
+	var second bytes.Buffer
+	if err := loaded.Write(&second); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(first.Bytes(), second.Bytes()) {
+		t.Errorf("written again as\n%s\nwant\n%s", second.Bytes(), first.Bytes())
+	}
+
+	// the default weights are kept in the form Write produces
+	var def bytes.Buffer
+	if err := DefaultWeights().Write(&def); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(def.Bytes(), defaultWeights) {
+		t.Error("weights.json is not laid out as Write lays it out")
+	}
+}
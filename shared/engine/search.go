@@ -0,0 +1,407 @@
+package engine
+
+import (
+	"context"
+	"time"
+
+	"github.com/tygermarshall/blunderbuss/shared/board"
+	"github.com/tygermarshall/blunderbuss/shared/pieces"
+)
+
+// maxPly bounds how far from the root the search can get, quiescence
+// search included.
+const maxPly = 2 * MaxDepth
+
+// checkInterval is how many nodes are searched between checks of the
+// deadline and the context.
+const checkInterval = 2048
+
+// search holds the state of one search.
+type search struct {
+	ctx      context.Context
+	deadline time.Time
+	stopped  bool
+	nodes    int64
+	tt       *table
+	eval     Evaluator
+
+	board board.Board
+	ply   int
+	// keys holds the hash of each position played before the root, then
+	// of each from the root down to the current one, for spotting
+	// repetitions.
+	keys []uint64
+
+	// moves and scores hold the move list at each ply and the ordering
+	// score of each move.
+	moves  [maxPly][]board.Move
+	scores [maxPly][]int
+
+	// killers holds, for each ply, the last two quiet moves that caused a
+	// beta cutoff there. Sibling positions tend to be refuted by the same
+	// move.
+	killers [maxPly][2]board.Move
+	// history scores quiet moves by team, from square and to square by how
+	// often and how deep they have caused cutoffs anywhere in the tree.
+	history [2][64][64]int
+
+	// pv[ply] is the best line found from ply, pvLen[ply] long.
+	pv    [maxPly][maxPly]board.Move
+	pvLen [maxPly]int
+}
+
+func newSearch(ctx context.Context, b board.Board, history []uint64, tt *table, eval Evaluator) *search {
+	s := &search{ctx: ctx, board: b, tt: tt, eval: eval, keys: make([]uint64, 0, len(history)+maxPly)}
+	s.keys = append(s.keys, history...)
+	s.keys = append(s.keys, b.Hash())
+	return s
+}
+
+// deepen searches iteratively deeper from depth from to depth to, until
+// it reaches to, stops or finds a mate, recording the result of each
+// finished iteration in r.
+func (s *search) deepen(from, to int, r *Result) {
+	for d := from; d <= to; d++ {
+		if s.checkStop(); s.stopped {
+			return
+		}
+		score := s.negamax(d, -infinity, infinity)
+		if s.stopped {
+			return
+		}
+		r.Score = score
+		r.Depth = d
+		r.PV = append(r.PV[:0], s.pv[0][:s.pvLen[0]]...)
+		r.Move = r.PV[0]
+		// a found mate will not get any shorter by searching deeper
+		if score >= MateScore-d || score <= -(MateScore-d) {
+			return
+		}
+	}
+}
+
+// negamax returns the score of the current position searched depth plies
+// deep, as seen by the side to move. A score at or below alpha is an upper
+// bound on the true score, and one at or above beta a lower bound.
+func (s *search) negamax(depth, alpha, beta int) int {
+	s.pvLen[s.ply] = 0
+	if s.ply > 0 && s.isDraw() {
+		return 0
+	}
+	if depth <= 0 {
+		return s.quiesce(alpha, beta, 0)
+	}
+	s.nodes++
+	if s.nodes%checkInterval == 0 {
+		s.checkStop()
+	}
+	if s.stopped {
+		return 0
+	}
+	if s.ply == maxPly-1 {
+		return s.eval.Evaluate(&s.board)
+	}
+
+	key := s.board.Hash()
+	var hashMove ttMove
+	if e, ok := s.tt.probe(key); ok {
+		hashMove = e.move
+		if s.ply > 0 && e.depth >= depth {
+			score := scoreFromTT(e.score, s.ply)
+			switch {
+			case e.bound == exact,
+				e.bound == lower && score >= beta,
+				e.bound == upper && score <= alpha:
+				return score
+			}
+		}
+	}
+
+	inCheck := s.board.InCheck()
+	if inCheck {
+		// look one ply further at checks, so that they are not cut off
+		// just before the reply
+		depth++
+	}
+
+	moves := s.board.AppendLegalMoves(s.moves[s.ply][:0])
+	s.moves[s.ply] = moves
+	if len(moves) == 0 {
+		if inCheck {
+			return -MateScore + s.ply
+		}
+		return 0
+	}
+	scores := s.scoreMoves(moves, hashMove)
+
+	best, bestMove, kind := -infinity, moves[0], exact
+	originalAlpha := alpha
+	for i := range moves {
+		pickMove(moves, scores, i)
+		m := moves[i]
+
+		u := s.makeMove(m)
+		var score int
+		if i == 0 {
+			score = -s.negamax(depth-1, -beta, -alpha)
+		} else {
+			// once a best move is in hand, prove the others worse with a
+			// null window around alpha and only search again in full if
+			// one is not
+			score = -s.negamax(depth-1, -alpha-1, -alpha)
+			if score > alpha && score < beta {
+				score = -s.negamax(depth-1, -beta, -alpha)
+			}
+		}
+		s.unmakeMove(u)
+		if s.stopped {
+			return 0
+		}
+
+		if score > best {
+			best, bestMove = score, m
+		}
+		if score > alpha {
+			alpha = score
+			s.updatePV(m)
+		}
+		if alpha >= beta {
+			if isQuiet(m) {
+				s.rememberCutoff(m, depth)
+			}
+			break
+		}
+	}
+
+	switch {
+	case best >= beta:
+		kind = lower
+	case best <= originalAlpha:
+		kind = upper
+	}
+	s.tt.store(key, ttEntry{move: packMove(bestMove), score: scoreToTT(best, s.ply), depth: depth, bound: kind})
+	return best
+}
+
+// quiesce searches only captures and promotions from the current position
+// until it is quiet, so that the evaluation is never taken in the middle
+// of an exchange. At its first ply it also tries moves that give check,
+// and a side in check tries every move. A side not in check may stand pat
+// on the evaluation instead of capturing.
+func (s *search) quiesce(alpha, beta, qply int) int {
+	s.pvLen[s.ply] = 0
+	s.nodes++
+	if s.nodes%checkInterval == 0 {
+		s.checkStop()
+	}
+	if s.stopped {
+		return 0
+	}
+	if s.ply == maxPly-1 {
+		return s.eval.Evaluate(&s.board)
+	}
+
+	inCheck := s.board.InCheck()
+	best := -infinity
+	if !inCheck {
+		best = s.eval.Evaluate(&s.board)
+		if best >= beta {
+			return best
+		}
+		if best > alpha {
+			alpha = best
+		}
+	}
+
+	var moves []board.Move
+	if inCheck || qply == 0 {
+		moves = s.board.AppendLegalMoves(s.moves[s.ply][:0])
+	} else {
+		moves = s.board.AppendLegalCaptures(s.moves[s.ply][:0])
+	}
+	s.moves[s.ply] = moves
+	if inCheck && len(moves) == 0 {
+		return -MateScore + s.ply
+	}
+	scores := s.scoreMoves(moves, 0)
+
+	for i := range moves {
+		pickMove(moves, scores, i)
+		m := moves[i]
+
+		u := s.makeMove(m)
+		if !inCheck && isQuiet(m) && !s.board.InCheck() {
+			s.unmakeMove(u)
+			continue
+		}
+		score := -s.quiesce(-beta, -alpha, qply+1)
+		s.unmakeMove(u)
+		if s.stopped {
+			return 0
+		}
+
+		if score > best {
+			best = score
+		}
+		if score > alpha {
+			alpha = score
+			if alpha >= beta {
+				break
+			}
+		}
+	}
+	return best
+}
+
+func (s *search) makeMove(m board.Move) board.Undo {
+	u := s.board.Make(m)
+	s.ply++
+	s.keys = append(s.keys, s.board.Hash())
+	return u
+}
+
+func (s *search) unmakeMove(u board.Undo) {
+	s.keys = s.keys[:len(s.keys)-1]
+	s.ply--
+	s.board.Unmake(u)
+}
+
+// updatePV makes m followed by the best line from the next ply the best
+// line from the current ply.
+func (s *search) updatePV(m board.Move) {
+	ply := s.ply
+	s.pv[ply][0] = m
+	n := copy(s.pv[ply][1:], s.pv[ply+1][:s.pvLen[ply+1]])
+	s.pvLen[ply] = n + 1
+}
+
+// rememberCutoff records that the quiet move m caused a beta cutoff in a
+// search depth plies deep.
+func (s *search) rememberCutoff(m board.Move, depth int) {
+	k := &s.killers[s.ply]
+	if !sameMove(k[0], m) {
+		k[1], k[0] = k[0], m
+	}
+	h := &s.history[m.Piece.Team][m.From][m.To]
+	*h += depth * depth
+	if *h >= historyMax {
+		// age every entry, keeping history scores below killer scores
+		for team := range s.history {
+			for from := range s.history[team] {
+				for to := range s.history[team][from] {
+					s.history[team][from][to] /= 2
+				}
+			}
+		}
+	}
+}
+
+// isDraw reports whether the current position is drawn by the fifty-move
+// rule, by repeating a position earlier in the search or the game, or
+// because neither side has the material to mate. A single repetition
+// counts, since a line that repeats once can be repeated again.
+func (s *search) isDraw() bool {
+	b := &s.board
+	if b.IsInsufficientMaterial() {
+		return true
+	}
+	if b.HalfmoveClock >= 100 {
+		// mate on the move that completes the fifty still wins
+		return !b.IsCheckmate()
+	}
+	key := s.keys[len(s.keys)-1]
+	// only positions since the last capture or pawn move can repeat, and
+	// only those with the same side to move
+	for i := len(s.keys) - 3; i >= 0 && i >= len(s.keys)-1-b.HalfmoveClock; i -= 2 {
+		if s.keys[i] == key {
+			return true
+		}
+	}
+	return false
+}
+
+// checkStop stops the search once the deadline has passed or the context
+// is done.
+func (s *search) checkStop() {
+	if !s.deadline.IsZero() && time.Now().After(s.deadline) {
+		s.stopped = true
+		return
+	}
+	select {
+	case <-s.ctx.Done():
+		s.stopped = true
+	default:
+	}
+}
+
+// Move ordering scores. Searching the best move first makes the rest of
+// the moves cheaper to refute, so the move the transposition table
+// remembers as best goes first, then captures of the most valuable piece
+// by the least valuable attacker and promotions, then the killer moves,
+// then the other quiet moves by their history.
+const (
+	hashMoveScore = 1 << 30
+	captureScore  = 1 << 20
+	killerScore   = 1 << 19
+	historyMax    = killerScore - 2
+)
+
+// orderValues are rough piece values for ordering captures, indexed by
+// pieces.PieceType.
+var orderValues = [...]int{
+	pieces.Pawn:   100,
+	pieces.Knight: 300,
+	pieces.Bishop: 300,
+	pieces.Rook:   500,
+	pieces.Queen:  900,
+	pieces.King:   0,
+	pieces.Empty:  0,
+}
+
+func (s *search) scoreMoves(moves []board.Move, hashMove ttMove) []int {
+	scores := s.scores[s.ply][:0]
+	killers := &s.killers[s.ply]
+	for _, m := range moves {
+		score := 0
+		switch {
+		case hashMove.is(m):
+			score = hashMoveScore
+		case m.IsCapture():
+			score = captureScore + 10*orderValues[m.Captured.Type] - orderValues[m.Piece.Type]/10 + orderValues[m.Promotion]
+		case m.Promotion != pieces.Empty:
+			score = captureScore + orderValues[m.Promotion]
+		case sameMove(m, killers[0]):
+			score = killerScore
+		case sameMove(m, killers[1]):
+			score = killerScore - 1
+		default:
+			score = s.history[m.Piece.Team][m.From][m.To]
+		}
+		scores = append(scores, score)
+	}
+	s.scores[s.ply] = scores
+	return scores
+}
+
+// pickMove swaps the best scored move from i onwards into place i. Picking
+// one move at a time costs less than sorting the list when a cutoff comes
+// after the first few moves.
+func pickMove(moves []board.Move, scores []int, i int) {
+	best := i
+	for j := i + 1; j < len(moves); j++ {
+		if scores[j] > scores[best] {
+			best = j
+		}
+	}
+	moves[i], moves[best] = moves[best], moves[i]
+	scores[i], scores[best] = scores[best], scores[i]
+}
+
+// isQuiet reports whether m neither captures nor promotes.
+func isQuiet(m board.Move) bool {
+	return !m.IsCapture() && m.Promotion == pieces.Empty
+}
+
+func sameMove(a, b board.Move) bool {
+	return a.From == b.From && a.To == b.To && a.Promotion == b.Promotion
+}
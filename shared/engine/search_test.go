@@ -0,0 +1,65 @@
+package engine
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tygermarshall/blunderbuss/shared/board"
+)
+
+// A checkmate on the move that completes fifty moves without a capture or
+// pawn move wins rather than draws.
+func TestMateOnFiftiethMove(t *testing.T) {
+	b, err := board.ParseFEN("7k/5Q2/6K1/8/8/8/8/8 w - - 99 80")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := New(Options{HashMB: 1}).Search(context.Background(), b, Limits{Depth: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Move.UCI() != "f7g7" || r.Score != MateScore-1 {
+		t.Errorf("got %s scoring %d, want f7g7 mating in one (%d)", r.Move.UCI(), r.Score, MateScore-1)
+	}
+}
+
+// A move back to a position from earlier in the game draws, so the side
+// that is losing plays it, but only when the search is told the history.
+func TestRepetitionWithGameHistory(t *testing.T) {
+	// 1. Kh1 Kd7 2. Kg1 Kd8 leaves white a queen down, and Kh1 repeats
+	// the position after 1. Kh1
+	var history []uint64
+	for _, fen := range []string{
+		"3k4/2q5/8/8/8/8/P7/7K b - - 1 1",
+		"8/2qk4/8/8/8/8/P7/7K w - - 2 2",
+		"8/2qk4/8/8/8/8/P7/6K1 b - - 3 2",
+	} {
+		b, err := board.ParseFEN(fen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		history = append(history, b.Hash())
+	}
+	b, err := board.ParseFEN("3k4/2q5/8/8/8/8/P7/6K1 w - - 4 3")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := New(Options{HashMB: 1})
+	r, err := e.Search(context.Background(), b, Limits{Depth: 4, History: history})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Move.UCI() != "g1h1" || r.Score != 0 {
+		t.Errorf("with the history, got %s scoring %d, want g1h1 drawing", r.Move.UCI(), r.Score)
+	}
+
+	e.Clear()
+	r, err = e.Search(context.Background(), b, Limits{Depth: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Score > -500 {
+		t.Errorf("without the history, got %s scoring %d, want a lost score", r.Move.UCI(), r.Score)
+	}
+}
@@ -0,0 +1,121 @@
+package engine
+
+import (
+	"math/bits"
+	"sync/atomic"
+
+	"github.com/tygermarshall/blunderbuss/shared/board"
+)
+
+// bound says how a stored score relates to the true score of a position.
+type bound uint8
+
+const (
+	// exact scores are the true score.
+	exact bound = iota + 1
+	// lower scores failed high: the true score is at least this.
+	lower
+	// upper scores failed low: the true score is at most this.
+	upper
+)
+
+// ttEntry is what the transposition table remembers about a position.
+type ttEntry struct {
+	move  ttMove
+	score int
+	depth int
+	bound bound
+}
+
+// ttMove is a move packed into 16 bits: from square, to square and
+// promotion piece type. The zero ttMove is no move, since no move goes
+// from a1 to a1.
+type ttMove uint16
+
+func packMove(m board.Move) ttMove {
+	return ttMove(m.From) | ttMove(m.To)<<6 | ttMove(m.Promotion)<<12
+}
+
+// is reports whether p is a packing of m.
+func (p ttMove) is(m board.Move) bool {
+	return p != 0 && p == packMove(m)
+}
+
+// table is a fixed-size transposition table, a hash table of search
+// results keyed by Zobrist hash. Each slot is two words, the entry and the
+// key xor the entry, written and read atomically. A slot torn by two
+// goroutines writing at once fails the key check on the next read and is
+// treated as empty, so the table needs no locks.
+type table struct {
+	slots []ttSlot
+	mask  uint64
+}
+
+type ttSlot struct {
+	check atomic.Uint64
+	data  atomic.Uint64
+}
+
+// newTable returns a table of at most mb megabytes, rounded down to a
+// power of two slots.
+func newTable(mb int) *table {
+	n := uint64(mb) << 20 / 16
+	if n < 1 {
+		n = 1
+	}
+	n = 1 << (63 - bits.LeadingZeros64(n))
+	return &table{slots: make([]ttSlot, n), mask: n - 1}
+}
+
+func (t *table) clear() {
+	for i := range t.slots {
+		t.slots[i].check.Store(0)
+		t.slots[i].data.Store(0)
+	}
+}
+
+// probe returns the entry stored for key, if there is one.
+func (t *table) probe(key uint64) (ttEntry, bool) {
+	slot := &t.slots[key&t.mask]
+	data := slot.data.Load()
+	if data == 0 || slot.check.Load()^data != key {
+		return ttEntry{}, false
+	}
+	return ttEntry{
+		move:  ttMove(data),
+		score: int(int16(data >> 16)),
+		depth: int(uint8(data >> 32)),
+		bound: bound(data >> 40),
+	}, true
+}
+
+// store records e for key, replacing whatever the slot held.
+func (t *table) store(key uint64, e ttEntry) {
+	data := uint64(e.move) | uint64(uint16(int16(e.score)))<<16 | uint64(uint8(e.depth))<<32 | uint64(e.bound)<<40
+	slot := &t.slots[key&t.mask]
+	slot.data.Store(data)
+	slot.check.Store(key ^ data)
+}
+
+// Mate scores in the table are stored relative to the position rather than
+// the root, so that they stay right when the position is reached at a
+// different ply.
+func scoreToTT(score, ply int) int {
+	switch {
+	case score > MateScore-maxPly:
+		return score + ply
+	case score < -(MateScore - maxPly):
+		return score - ply
+	}
+	return score
+}
+
+func scoreFromTT(score, ply int) int {
+	switch {
+	case score > MateScore-maxPly:
+		return score - ply
+	case score < -(MateScore - maxPly):
+		return score + ply
+	}
+	return score
+}
@@ -0,0 +1,122 @@
+package engine
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tygermarshall/blunderbuss/shared/board"
+	"github.com/tygermarshall/blunderbuss/shared/pieces"
+)
+
+func TestTableStoreProbe(t *testing.T) {
+	tt := newTable(1)
+	if n := len(tt.slots); n != 1<<16 || tt.mask != uint64(n-1) {
+		t.Fatalf("1 MB table has %d slots and mask %#x", n, tt.mask)
+	}
+
+	m := board.NewMove(board.E7, board.E8)
+	m.Promotion = pieces.Queen
+	entries := []ttEntry{
+		{move: packMove(m), score: -1234, depth: 7, bound: exact},
+		{move: packMove(board.NewMove(board.A1, board.H8)), score: MateScore - 3, depth: MaxDepth, bound: lower},
+		{score: -(MateScore - 10), depth: 0, bound: upper},
+	}
+	const key = 0x0123456789abcdef
+	for _, e := range entries {
+		tt.store(key, e)
+		got, ok := tt.probe(key)
+		if !ok || got != e {
+			t.Errorf("stored %+v, probed %+v, %v", e, got, ok)
+		}
+		if !got.move.is(m) && e.move == packMove(m) {
+			t.Errorf("move %#x does not unpack to %s", got.move, m.UCI())
+		}
+	}
+
+	// a key that maps to the same slot replaces the entry, and the old key
+	// no longer finds it
+	other := key + uint64(len(tt.slots))
+	tt.store(other, entries[0])
+	if _, ok := tt.probe(key); ok {
+		t.Error("probe found an entry stored under another key")
+	}
+	if got, ok := tt.probe(other); !ok || got != entries[0] {
+		t.Errorf("probe(other) = %+v, %v", got, ok)
+	}
+	if _, ok := tt.probe(other + uint64(len(tt.slots))); ok {
+		t.Error("probe found an entry for a key never stored")
+	}
+
+	// a slot torn by two writers fails the key check
+	slot := &tt.slots[other&tt.mask]
+	slot.data.Store(slot.data.Load() ^ 1<<20)
+	if _, ok := tt.probe(other); ok {
+		t.Error("probe accepted a torn slot")
+	}
+
+	tt.store(key, entries[0])
+	tt.clear()
+	if _, ok := tt.probe(key); ok {
+		t.Error("probe found an entry after clear")
+	}
+}
+
+func TestMateScoresInTable(t *testing.T) {
+	for _, score := range []int{0, 250, -250, MateScore - 5, -(MateScore - 5)} {
+		for _, ply := range []int{0, 1, 4, 20} {
+			if got := scoreFromTT(scoreToTT(score, ply), ply); got != score {
+				t.Errorf("score %d at ply %d comes back as %d", score, ply, got)
+			}
+		}
+	}
+	// a mate in 5 plies from the root, stored 3 plies down, is a mate in 2
+	// from the stored position and a mate in 3 when found again at ply 1
+	if got := scoreFromTT(scoreToTT(MateScore-5, 3), 1); got != MateScore-3 {
+		t.Errorf("got %d, want %d", got, MateScore-3)
+	}
+}
+
+// TestQuiescence checks that quiescence search plays out captures before
+// trusting the evaluation: a loose rook is worth taking, one defended by
+// a pawn or a knight is not, a knight is worth a pawn even when defended,
+// and a depth 1 search chooses accordingly.
+func TestQuiescence(t *testing.T) {
+	eval := NewTaperedEvaluator(DefaultWeights())
+	tests := []struct {
+		name string
+		fen  string
+		// gain is how much better than the static evaluation the
+		// position is, at least or at most
+		minGain, maxGain int
+		move             string
+	}{
+		{"loose rook", "4k3/8/8/3r4/8/8/8/3QK3 w - - 0 1", 300, infinity, "d1d5"},
+		{"rook defended by a pawn", "4k3/8/4p3/3r4/8/8/8/3QK3 w - - 0 1", 0, 100, ""},
+		{"rook defended by a knight", "4k3/4n3/8/3r4/8/8/8/3QK3 w - - 0 1", 0, 100, ""},
+		// exd5 exd5 trades a pawn for a knight
+		{"exchange", "4k3/8/4p3/3n4/4P3/8/8/4K3 w - - 0 1", 150, 300, "e4d5"},
+	}
+	for _, tt := range tests {
+		b, err := board.ParseFEN(tt.fen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		static := eval.Evaluate(&b)
+		s := newSearch(context.Background(), b, nil, newTable(1), eval)
+		gain := s.quiesce(-infinity, infinity, 0) - static
+		if gain < tt.minGain || gain > tt.maxGain {
+			t.Errorf("%s: quiescence gains %d over the static %d, want %d to %d", tt.name, gain, static, tt.minGain, tt.maxGain)
+		}
+
+		r, err := New(Options{HashMB: 1}).Search(context.Background(), b, Limits{Depth: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		switch {
+		case tt.move != "" && r.Move.UCI() != tt.move:
+			t.Errorf("%s: depth 1 plays %s, want %s", tt.name, r.Move.UCI(), tt.move)
+		case tt.maxGain < infinity && r.Move.UCI() == "d1d5":
+			t.Errorf("%s: depth 1 gives the queen for a rook", tt.name)
+		}
+	}
+}
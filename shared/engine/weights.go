@@ -0,0 +1,150 @@
+package engine
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+)
+
+// Pair is a middlegame and an endgame value. The evaluation blends the two
+// by how much material is left on the board.
+type Pair [2]int
+
+// Weights are the values of the terms of a TaperedEvaluator, in
+// centipawns. Every term is counted for white and against black, and
+// tables indexed by square are laid out from white's point of view like a
+// diagram, a8 to h8 first and a1 to h1 last; black uses them mirrored.
+type Weights struct {
+	// Material is the value of each piece type, pawn to queen.
+	Material [5]Pair `json:"material"`
+	// PST is the piece-square table of each piece type, pawn to king: a
+	// bonus for a piece of that type standing on each square.
+	PST [6][64]Pair `json:"pst"`
+	// BishopPair is a bonus for having two bishops or more.
+	BishopPair Pair `json:"bishop_pair"`
+	// DoubledPawn is counted for each pawn beyond the first on a file.
+	DoubledPawn Pair `json:"doubled_pawn"`
+	// IsolatedPawn is counted for each pawn with no friendly pawn on
+	// either neighbouring file.
+	IsolatedPawn Pair `json:"isolated_pawn"`
+	// PassedPawn is the bonus for a pawn with no enemy pawn ahead of it on
+	// its own or a neighbouring file, by its rank counted from its own
+	// side, 0 to 7.
+	PassedPawn [8]Pair `json:"passed_pawn"`
+	// Mobility is counted for each square a knight, bishop, rook or queen
+	// attacks that holds no friendly piece and is not attacked by an enemy
+	// pawn.
+	Mobility [4]Pair `json:"mobility"`
+	// KingShield is counted for each friendly pawn on the two ranks in
+	// front of the king and on the king's file or a neighbouring one.
+	KingShield Pair `json:"king_shield"`
+	// KingAttack is counted for each attack by an enemy knight, bishop,
+	// rook or queen on a square next to the king.
+	KingAttack Pair `json:"king_attack"`
+}
+
+// Params returns a pointer to every Pair in w in a fixed order, so that a
+// tuner can treat the weights as one vector.
+func (w *Weights) Params() []*Pair {
+	params := make([]*Pair, 0, 406)
+	for i := range w.Material {
+		params = append(params, &w.Material[i])
+	}
+	for pt := range w.PST {
+		for sq := range w.PST[pt] {
+			params = append(params, &w.PST[pt][sq])
+		}
+	}
+	params = append(params, &w.BishopPair, &w.DoubledPawn, &w.IsolatedPawn)
+	for i := range w.PassedPawn {
+		params = append(params, &w.PassedPawn[i])
+	}
+	for i := range w.Mobility {
+		params = append(params, &w.Mobility[i])
+	}
+	return append(params, &w.KingShield, &w.KingAttack)
+}
+
+//go:embed weights.json
+var defaultWeights []byte
+
+// DefaultWeights returns the weights the engine evaluates with unless told
+// otherwise.
+func DefaultWeights() Weights {
+	var w Weights
+	if err := json.Unmarshal(defaultWeights, &w); err != nil {
+		panic("engine: bad default weights: " + err.Error())
+	}
+	return w
+}
+
+// ReadWeights reads weights in the JSON form Write produces. Terms missing
+// from the input keep their default values, so a file need only hold the
+// terms being experimented with. Unknown terms are an error.
+func ReadWeights(r io.Reader) (Weights, error) {
+	w := DefaultWeights()
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&w); err != nil {
+		return Weights{}, err
+	}
+	return w, nil
+}
+
+// LoadWeights reads weights from the JSON file at path, as ReadWeights
+// does.
+func LoadWeights(path string) (Weights, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Weights{}, err
+	}
+	defer f.Close()
+	w, err := ReadWeights(f)
+	if err != nil {
+		return Weights{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return w, nil
+}
+
+var (
+	// pairPattern matches a Pair as json.MarshalIndent lays it out.
+	pairPattern = regexp.MustCompile(`\[\s+(-?\d+),\s+(-?\d+)\s+\]`)
+	// tablePattern matches a table of 64 compacted pairs.
+	tablePattern = regexp.MustCompile(`\[\n(?:\s+\[-?\d+, -?\d+\],?\n){64}\s+\]`)
+)
+
+// Write writes w as indented JSON, with each Pair on one line and the
+// piece-square tables laid out eight squares to a line, like a diagram.
+func (w Weights) Write(out io.Writer) error {
+	data, err := json.MarshalIndent(w, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = pairPattern.ReplaceAll(data, []byte("[$1, $2]"))
+	data = tablePattern.ReplaceAllFunc(data, func(table []byte) []byte {
+		lines := bytes.Split(table, []byte("\n"))
+		pairs, end := lines[1:65], lines[65]
+		indent := pairs[0][:len(pairs[0])-len(bytes.TrimLeft(pairs[0], " "))]
+		var b bytes.Buffer
+		b.WriteString("[\n")
+		for rank := 0; rank < 8; rank++ {
+			b.Write(indent)
+			for file := 0; file < 8; file++ {
+				if file > 0 {
+					b.WriteByte(' ')
+				}
+				b.Write(bytes.TrimSpace(pairs[rank*8+file]))
+			}
+			b.WriteByte('\n')
+		}
+		b.Write(end)
+		return b.Bytes()
+	})
+	data = append(data, '\n')
+	_, err = out.Write(data)
+	return err
+}
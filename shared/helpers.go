@@ -19,10 +19,10 @@ const (
 )
 
 func CreatePrettyPrint(b board.Board, output *strings.Builder) {
-	for rank := 0; rank <= 7; rank++ {
+	for rank := 7; rank >= 0; rank-- {
 
 		for file := 0; file < 8; file++ {
-			p := b.Squares[rank][file]
+			p := b.PieceAt(board.NewSquare(file, rank))
 
 			bg := squareColor(rank, file)
 			output.WriteString(bg)
@@ -45,11 +45,11 @@ func CreatePrettyPrint(b board.Board, output *strings.Builder) {
 }
 
 func PrettyPrint(b board.Board) {
-	for rank := 0; rank <= 7; rank++ {
+	for rank := 7; rank >= 0; rank-- {
 		fmt.Printf("%d ", rank+1)
 
 		for file := 0; file < 8; file++ {
-			p := b.Squares[rank][file]
+			p := b.PieceAt(board.NewSquare(file, rank))
 
 			bg := squareColor(rank, file)
 			fmt.Print(bg)
@@ -71,11 +71,13 @@ func PrettyPrint(b board.Board) {
 
 	fmt.Println("  a b c d e f g h")
 }
+
+// squareColor returns the background for a square; a1 is dark.
 func squareColor(rank, file int) string {
 	if (rank+file)%2 == 0 {
-		return bgLight
+		return bgDark
 	}
-	return bgDark
+	return bgLight
 }
 
 func pieceRune(p pieces.Piece) string {
@@ -118,7 +120,7 @@ func pieceRune(p pieces.Piece) string {
 func LogBoard(b *board.Board) {
 	for rank := 7; rank >= 0; rank-- {
 		for file := 0; file < 8; file++ {
-			p := b.Squares[rank][file]
+			p := b.PieceAt(board.NewSquare(file, rank))
 			if p.Type == pieces.Empty {
 				log.Print(". ")
 				continue
@@ -136,7 +138,7 @@ func LogBoard(b *board.Board) {
 func PrintBoard(b *board.Board) {
 	for rank := 7; rank >= 0; rank-- {
 		for file := 0; file < 8; file++ {
-			p := b.Squares[rank][file]
+			p := b.PieceAt(board.NewSquare(file, rank))
 			if p.Type == pieces.Empty {
 				fmt.Print(". ")
 				continue
@@ -0,0 +1,190 @@
+package pgn
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Command is a command embedded in a comment, such as [%clk 1:05:12] or
+// [%eval -0.35].
+type Command struct {
+	Name  string
+	Value string
+}
+
+func (c Command) String() string {
+	return fmt.Sprintf("[%%%s %s]", c.Name, c.Value)
+}
+
+var commandPattern = regexp.MustCompile(`\[%(\w+)\s*([^\]]*)\]`)
+
+// addComment appends text to n's comment, taking any embedded commands
+// out of it.
+func (n *Node) addComment(text string) {
+	for _, m := range commandPattern.FindAllStringSubmatch(text, -1) {
+		n.SetCommand(m[1], strings.TrimSpace(m[2]))
+	}
+	n.Comment = joinComments(n.Comment, tidyComment(commandPattern.ReplaceAllString(text, " ")))
+}
+
+// tidyComment collapses the spaces in each line of a comment and drops
+// blank lines at either end, keeping the line breaks in between.
+func tidyComment(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.Join(strings.Fields(line), " ")
+	}
+	return strings.Trim(strings.Join(lines, "\n"), "\n")
+}
+
+// commentText returns n's commands followed by its comment, as written
+// between braces.
+func (n *Node) commentText() string {
+	parts := make([]string, 0, len(n.Commands)+1)
+	for _, c := range n.Commands {
+		parts = append(parts, c.String())
+	}
+	return joinComments(strings.Join(parts, " "), n.Comment)
+}
+
+func joinComments(a, b string) string {
+	switch {
+	case a == "":
+		return b
+	case b == "":
+		return a
+	}
+	return a + " " + b
+}
+
+// Command returns the value of the named command on n.
+func (n *Node) Command(name string) (string, bool) {
+	for _, c := range n.Commands {
+		if c.Name == name {
+			return c.Value, true
+		}
+	}
+	return "", false
+}
+
+// SetCommand sets the named command, replacing an existing value in place
+// or adding it at the end.
+func (n *Node) SetCommand(name, value string) {
+	for i, c := range n.Commands {
+		if c.Name == name {
+			n.Commands[i].Value = value
+			return
+		}
+	}
+	n.Commands = append(n.Commands, Command{Name: name, Value: value})
+}
+
+// DeleteCommand removes the named command from n.
+func (n *Node) DeleteCommand(name string) {
+	for i, c := range n.Commands {
+		if c.Name == name {
+			n.Commands = append(n.Commands[:i:i], n.Commands[i+1:]...)
+			return
+		}
+	}
+}
+
+// Clock returns the time left on the mover's clock after Move, from a
+// [%clk H:MM:SS] command.
+func (n *Node) Clock() (time.Duration, bool) {
+	value, ok := n.Command("clk")
+	if !ok {
+		return 0, false
+	}
+	d, err := parseClock(value)
+	return d, err == nil
+}
+
+// SetClock records the time left on the mover's clock after Move.
+func (n *Node) SetClock(d time.Duration) {
+	n.SetCommand("clk", formatClock(d))
+}
+
+func parseClock(s string) (time.Duration, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) > 3 {
+		return 0, fmt.Errorf("pgn: invalid clock %q", s)
+	}
+	var d time.Duration
+	for i, p := range parts {
+		if i < len(parts)-1 {
+			n, err := strconv.Atoi(p)
+			if err != nil || n < 0 {
+				return 0, fmt.Errorf("pgn: invalid clock %q", s)
+			}
+			d = (d + time.Duration(n)) * 60
+			continue
+		}
+		secs, err := strconv.ParseFloat(p, 64)
+		if err != nil || secs < 0 {
+			return 0, fmt.Errorf("pgn: invalid clock %q", s)
+		}
+		d = d*time.Second + time.Duration(secs*float64(time.Second))
+	}
+	return d, nil
+}
+
+func formatClock(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	h := d / time.Hour
+	m := d % time.Hour / time.Minute
+	s := d % time.Minute / time.Second
+	clock := fmt.Sprintf("%d:%02d:%02d", h, m, s)
+	if frac := d % time.Second; frac != 0 {
+		clock += strings.TrimRight(fmt.Sprintf(".%03d", frac/time.Millisecond), "0.")
+	}
+	return clock
+}
+
+// Eval is an engine evaluation from white's point of view: either a score
+// in centipawns or, if Mate is not zero, the number of moves to a forced
+// mate, negative when black mates.
+type Eval struct {
+	Centipawns int
+	Mate       int
+}
+
+func (e Eval) String() string {
+	if e.Mate != 0 {
+		return fmt.Sprintf("#%d", e.Mate)
+	}
+	return strconv.FormatFloat(float64(e.Centipawns)/100, 'f', 2, 64)
+}
+
+// Eval returns the evaluation after Move, from an [%eval] command such as
+// [%eval 0.35] or [%eval #-3]. A search depth after a comma is ignored.
+func (n *Node) Eval() (Eval, bool) {
+	value, ok := n.Command("eval")
+	if !ok {
+		return Eval{}, false
+	}
+	value, _, _ = strings.Cut(value, ",")
+	if mate, ok := strings.CutPrefix(value, "#"); ok {
+		moves, err := strconv.Atoi(mate)
+		if err != nil || moves == 0 {
+			return Eval{}, false
+		}
+		return Eval{Mate: moves}, true
+	}
+	pawns, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return Eval{}, false
+	}
+	return Eval{Centipawns: int(math.Round(pawns * 100))}, true
+}
+
+// SetEval records the evaluation after Move.
+func (n *Node) SetEval(e Eval) {
+	n.SetCommand("eval", e.String())
+}
@@ -0,0 +1,112 @@
+// Package pgn reads and writes chess games in Portable Game Notation.
+package pgn
+
+import (
+	"github.com/tygermarshall/blunderbuss/shared/board"
+)
+
+// Game results as written in the Result tag and at the end of the movetext.
+const (
+	ResultWhiteWins = "1-0"
+	ResultBlackWins = "0-1"
+	ResultDraw      = "1/2-1/2"
+	ResultUnknown   = "*"
+)
+
+// SevenTagRoster lists the tags every exported game carries, in the order
+// they are written.
+var SevenTagRoster = []string{"Event", "Site", "Date", "Round", "White", "Black", "Result"}
+
+// Tag is a single tag pair such as [Event "Casual game"].
+type Tag struct {
+	Name  string
+	Value string
+}
+
+// Game is one game of a PGN file.
+type Game struct {
+	// Tags are kept in the order they were read or set.
+	Tags []Tag
+	// Root holds the starting position. The game's moves, with their
+	// variations, hang below it.
+	Root *Node
+	// Result is the game termination marker, one of the Result constants.
+	Result string
+}
+
+// NewGame returns a game from the standard starting position with no tags
+// or moves and an unknown result.
+func NewGame() *Game {
+	return &Game{Root: newRoot(board.CreateDefaultBoard()), Result: ResultUnknown}
+}
+
+// Tag returns the value of the named tag.
+func (g *Game) Tag(name string) (string, bool) {
+	for _, t := range g.Tags {
+		if t.Name == name {
+			return t.Value, true
+		}
+	}
+	return "", false
+}
+
+// SetTag sets the named tag, replacing an existing value in place or
+// adding it at the end.
+func (g *Game) SetTag(name, value string) {
+	for i, t := range g.Tags {
+		if t.Name == name {
+			g.Tags[i].Value = value
+			return
+		}
+	}
+	g.Tags = append(g.Tags, Tag{Name: name, Value: value})
+}
+
+// StartBoard returns the position the game starts from.
+func (g *Game) StartBoard() board.Board {
+	return g.Root.Board
+}
+
+// SetStartBoard makes b the starting position, discarding any moves, and
+// adds the SetUp and FEN tags if it is not the standard one.
+func (g *Game) SetStartBoard(b board.Board) {
+	g.Root = newRoot(b)
+	fen := b.FEN()
+	if fen == board.StartFEN {
+		return
+	}
+	g.SetTag("SetUp", "1")
+	g.SetTag("FEN", fen)
+}
+
+// End returns the node at the end of the mainline.
+func (g *Game) End() *Node {
+	n := g.Root
+	for next := n.Next(); next != nil; next = n.Next() {
+		n = next
+	}
+	return n
+}
+
+// Board returns the position after the last move of the mainline.
+func (g *Game) Board() board.Board {
+	return g.End().Board
+}
+
+// Mainline returns the moves of the mainline, without variations.
+func (g *Game) Mainline() []board.Move {
+	return g.End().Moves()
+}
+
+// AppendMoves plays moves one after another at the end of the mainline.
+// It stops at the first illegal move and returns its error.
+func (g *Game) AppendMoves(moves ...board.Move) error {
+	n := g.End()
+	for _, m := range moves {
+		var err error
+		if n, err = n.Play(m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
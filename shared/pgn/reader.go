@@ -0,0 +1,221 @@
+package pgn
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/tygermarshall/blunderbuss/shared/board"
+)
+
+// Reader reads games one at a time from a PGN file that may hold many.
+type Reader struct {
+	s *scanner
+}
+
+// NewReader returns a Reader reading PGN from r.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{s: newScanner(r)}
+}
+
+// Parse reads every game in r. A game that cannot be read is skipped and
+// the games after it are still read; the error joins the reason for each
+// game skipped. Parse only stops early if reading r fails.
+func Parse(r io.Reader) ([]*Game, error) {
+	pr := NewReader(r)
+	var games []*Game
+	var errs []error
+	for {
+		g, err := pr.Next()
+		if err == io.EOF {
+			return games, errors.Join(errs...)
+		}
+		if err != nil {
+			errs = append(errs, err)
+			if pr.s.err != nil {
+				return games, errors.Join(errs...)
+			}
+			continue
+		}
+		games = append(games, g)
+	}
+}
+
+// ParseString reads every game in s.
+func ParseString(s string) ([]*Game, error) {
+	return Parse(strings.NewReader(s))
+}
+
+// Next reads the next game. It returns io.EOF when there are no more
+// games. If a game has a broken tag or an illegal or unreadable move, Next
+// skips the rest of that game and returns the error, so the caller may
+// carry on with the following one. Once reading the underlying input
+// fails, every call returns that error.
+func (r *Reader) Next() (*Game, error) {
+	g := NewGame()
+	sawTag := false
+	for {
+		t, err := r.s.peek()
+		if err != nil {
+			if r.s.err == nil {
+				r.skipGame()
+			}
+			return nil, err
+		}
+		if t.kind != tokenTag {
+			break
+		}
+		r.s.next()
+		g.SetTag(t.text, t.value)
+		sawTag = true
+	}
+
+	t, err := r.s.peek()
+	if err != nil {
+		return nil, err
+	}
+	if t.kind == tokenEOF && !sawTag {
+		return nil, io.EOF
+	}
+
+	if fen, ok := g.Tag("FEN"); ok {
+		b, err := board.ParseFEN(fen)
+		if err != nil {
+			r.skipGame()
+			return nil, fmt.Errorf("pgn: line %d: %w", t.line, err)
+		}
+		g.Root = newRoot(b)
+	}
+	if err := r.readMovetext(g); err != nil {
+		r.skipGame()
+		return nil, err
+	}
+	if g.Result == ResultUnknown {
+		if result, ok := g.Tag("Result"); ok {
+			g.Result = result
+		}
+	}
+	return g, nil
+}
+
+// readMovetext reads moves up to the game termination marker, the next
+// game's tags or the end of input.
+func (r *Reader) readMovetext(g *Game) error {
+	return r.readLine(g, g.Root, false)
+}
+
+// readLine reads a line of moves played from start, with the comments,
+// NAGs and variations on them. A variation ends at its ")", the mainline
+// at the game termination marker, the next game's tags or the end of
+// input.
+func (r *Reader) readLine(g *Game, start *Node, variation bool) error {
+	cur := start
+	startingComment := ""
+	for {
+		t, err := r.s.peek()
+		if err != nil {
+			return err
+		}
+		switch t.kind {
+		case tokenEOF, tokenTag:
+			if variation {
+				return fmt.Errorf("%w: line %d: unterminated variation", ErrSyntax, t.line)
+			}
+			return nil
+		case tokenResult:
+			if variation {
+				// the marker is left for skipGame, so the game ends there
+				return fmt.Errorf("%w: line %d: result inside a variation", ErrSyntax, t.line)
+			}
+		}
+		r.s.next()
+
+		switch t.kind {
+		case tokenResult:
+			g.Result = t.text
+			return nil
+		case tokenComment:
+			if variation && cur == start {
+				startingComment = joinComments(startingComment, tidyComment(t.text))
+			} else {
+				cur.addComment(t.text)
+			}
+		case tokenNAG:
+			if cur != start {
+				cur.NAGs = append(cur.NAGs, t.nag)
+			}
+		case tokenOpenVariation:
+			if cur == start {
+				return fmt.Errorf("%w: line %d: variation before any move", ErrSyntax, t.line)
+			}
+			if err := r.readLine(g, cur.Parent, true); err != nil {
+				return err
+			}
+		case tokenCloseVariation:
+			if !variation {
+				return fmt.Errorf("%w: line %d: unmatched )", ErrSyntax, t.line)
+			}
+			return nil
+		case tokenSymbol:
+			san := stripMoveNumber(t.text)
+			if san == "" {
+				continue
+			}
+			san, nag := splitSuffix(san)
+			m, err := cur.Board.ParseSAN(san)
+			if err != nil {
+				return fmt.Errorf("pgn: line %d: %w", t.line, err)
+			}
+			next, err := cur.Play(m)
+			if err != nil {
+				return fmt.Errorf("pgn: line %d: %w", t.line, err)
+			}
+			next.StartingComment = joinComments(next.StartingComment, startingComment)
+			startingComment = ""
+			if nag != 0 {
+				next.NAGs = append(next.NAGs, nag)
+			}
+			cur = next
+		}
+	}
+}
+
+// suffixNAGs maps move suffix annotations to the NAGs they stand for.
+var suffixNAGs = map[string]int{"!": 1, "?": 2, "!!": 3, "??": 4, "!?": 5, "?!": 6}
+
+// splitSuffix removes a suffix annotation such as "!?" from san, returning
+// its NAG, or 0 if there was none.
+func splitSuffix(san string) (string, int) {
+	move := strings.TrimRight(san, "!?")
+	return move, suffixNAGs[san[len(move):]]
+}
+
+// skipGame discards the rest of a broken game, up to its termination
+// marker or the next game's tags. Syntax errors in the rest are skipped
+// too, since the scanner always reads past what it could not make sense of.
+func (r *Reader) skipGame() {
+	for {
+		t, err := r.s.peek()
+		if err != nil && r.s.err == nil {
+			continue
+		}
+		if err != nil || t.kind == tokenEOF || t.kind == tokenTag {
+			return
+		}
+		r.s.next()
+		if t.kind == tokenResult {
+			return
+		}
+	}
+}
+
+// stripMoveNumber removes a leading move number such as "12." or "12..."
+// from a symbol, returning "" if the symbol was only a move number.
+func stripMoveNumber(sym string) string {
+	s := strings.TrimLeft(sym, "0123456789")
+	if len(s) == len(sym) {
+		return sym
+	}
+	return strings.TrimLeft(s, ".")
+}
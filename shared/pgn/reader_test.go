@@ -0,0 +1,204 @@
+package pgn
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tygermarshall/blunderbuss/shared/board"
+)
+
+// parseOne parses s, which must hold exactly one game.
+func parseOne(t *testing.T, s string) *Game {
+	t.Helper()
+	games, err := ParseString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(games) != 1 {
+		t.Fatalf("got %d games, want 1", len(games))
+	}
+	return games[0]
+}
+
+// mainline returns the mainline of g in UCI.
+func mainline(g *Game) string {
+	var moves []string
+	for _, m := range g.Mainline() {
+		moves = append(moves, m.UCI())
+	}
+	return strings.Join(moves, " ")
+}
+
+func TestReadTagsAndMoves(t *testing.T) {
+	g := parseOne(t, `[Event "Casual \"blitz\" game"]
+[Site "Here"]
+[Result "1-0"]
+
+1. e4 e5 2. Nf3 Nc6 3. Bb5 a6 1-0
+`)
+	if v, _ := g.Tag("Event"); v != `Casual "blitz" game` {
+		t.Errorf("Event = %q", v)
+	}
+	if v, _ := g.Tag("Site"); v != "Here" {
+		t.Errorf("Site = %q", v)
+	}
+	if got, want := mainline(g), "e2e4 e7e5 g1f3 b8c6 f1b5 a7a6"; got != want {
+		t.Errorf("mainline %q, want %q", got, want)
+	}
+	if g.Result != ResultWhiteWins {
+		t.Errorf("result %q, want 1-0", g.Result)
+	}
+}
+
+func TestReadByteOrderMarkAndEscapes(t *testing.T) {
+	g := parseOne(t, "\xef\xbb\xbf[Event \"x\"]\n% a line for some other program\n\n1. d4 d5\n%1. e4 is not a move\n2. c4 *\n")
+	if v, ok := g.Tag("Event"); !ok || v != "x" {
+		t.Errorf("Event = %q, %v", v, ok)
+	}
+	if got, want := mainline(g), "d2d4 d7d5 c2c4"; got != want {
+		t.Errorf("mainline %q, want %q", got, want)
+	}
+}
+
+func TestReadComments(t *testing.T) {
+	g := parseOne(t, "{Before the game} 1. e4 {best by test} e5 ; rest of line\n2. Nf3 *")
+	if g.Root.Comment != "Before the game" {
+		t.Errorf("root comment %q", g.Root.Comment)
+	}
+	e4 := g.Root.Next()
+	if e4.Comment != "best by test" {
+		t.Errorf("e4 comment %q", e4.Comment)
+	}
+	if e5 := e4.Next(); e5.Comment != "rest of line" {
+		t.Errorf("e5 comment %q", e5.Comment)
+	}
+	if got, want := mainline(g), "e2e4 e7e5 g1f3"; got != want {
+		t.Errorf("mainline %q, want %q", got, want)
+	}
+}
+
+func TestReadNestedVariations(t *testing.T) {
+	g := parseOne(t, "1. e4 e5 (1... c5 2. Nf3 (2. c3 {Alapin} d5) 2... d6) (1... e6) 2. Nf3 *")
+	if got, want := mainline(g), "e2e4 e7e5 g1f3"; got != want {
+		t.Errorf("mainline %q, want %q", got, want)
+	}
+	afterE4 := g.Root.Next()
+	vars := afterE4.Variations()
+	if len(vars) != 2 {
+		t.Fatalf("got %d variations after 1. e4, want 2", len(vars))
+	}
+	if vars[0].Move.UCI() != "c7c5" || vars[1].Move.UCI() != "e7e6" {
+		t.Errorf("variations %s and %s, want c7c5 and e7e6", vars[0].Move.UCI(), vars[1].Move.UCI())
+	}
+	afterC5 := vars[0]
+	nested := afterC5.Variations()
+	if len(nested) != 1 || nested[0].Move.UCI() != "c2c3" {
+		t.Fatalf("nested variations after 1... c5: %v", nested)
+	}
+	if nested[0].Comment != "Alapin" || nested[0].Next().Move.UCI() != "d7d5" {
+		t.Errorf("nested line: comment %q, then %v", nested[0].Comment, nested[0].Next())
+	}
+	if got := afterC5.Next().Next().Move.UCI(); got != "d7d6" {
+		t.Errorf("line after 1... c5 2. Nf3 continues %s, want d7d6", got)
+	}
+	if afterC5.IsMainline() || !afterE4.IsMainline() {
+		t.Error("IsMainline is wrong")
+	}
+}
+
+func TestReadAnnotations(t *testing.T) {
+	g := parseOne(t, "1. e4! e5? 2. Nf3!! Nc6?? 3. Bb5!? a6?! 4. Ba4 $14 $32 *")
+	want := [][]int{{1}, {2}, {3}, {4}, {5}, {6}, {14, 32}}
+	n := g.Root
+	for i, nags := range want {
+		n = n.Next()
+		if !reflect.DeepEqual(n.NAGs, nags) {
+			t.Errorf("move %d %s: NAGs %v, want %v", i+1, n.Move.UCI(), n.NAGs, nags)
+		}
+	}
+}
+
+func TestReadSetUp(t *testing.T) {
+	g := parseOne(t, `[SetUp "1"]
+[FEN "4k3/8/8/8/8/8/4P3/4K3 b - - 0 40"]
+
+40... Kd7 41. e4 *`)
+	if got := g.StartBoard().FEN(); got != "4k3/8/8/8/8/8/4P3/4K3 b - - 0 40" {
+		t.Errorf("start %s", got)
+	}
+	if got, want := mainline(g), "e8d7 e2e4"; got != want {
+		t.Errorf("mainline %q, want %q", got, want)
+	}
+}
+
+func TestReadMultipleGames(t *testing.T) {
+	games, err := ParseString(`[Event "one"]
+
+1. e4 e5 1-0
+
+[Event "two"]
+
+1. d4 d5 0-1
+[Event "three"]
+1. c4 1/2-1/2
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct{ event, moves, result string }{
+		{"one", "e2e4 e7e5", ResultWhiteWins},
+		{"two", "d2d4 d7d5", ResultBlackWins},
+		{"three", "c2c4", ResultDraw},
+	}
+	if len(games) != len(want) {
+		t.Fatalf("got %d games, want %d", len(games), len(want))
+	}
+	for i, w := range want {
+		g := games[i]
+		if event, _ := g.Tag("Event"); event != w.event || mainline(g) != w.moves || g.Result != w.result {
+			t.Errorf("game %d: %s, %q, %s; want %s, %q, %s", i+1, event, mainline(g), g.Result, w.event, w.moves, w.result)
+		}
+	}
+}
+
+// The termination marker after the moves is the game's result; the Result
+// tag only fills in for a missing one.
+func TestReadResultConflict(t *testing.T) {
+	g := parseOne(t, "[Result \"1-0\"]\n\n1. e4 e5 0-1\n")
+	if g.Result != ResultBlackWins {
+		t.Errorf("result %q, want the marker's 0-1", g.Result)
+	}
+	g = parseOne(t, "[Result \"1-0\"]\n\n1. e4 e5\n")
+	if g.Result != ResultWhiteWins {
+		t.Errorf("result %q, want the tag's 1-0", g.Result)
+	}
+}
+
+func TestParseSkipsBrokenGames(t *testing.T) {
+	games, err := ParseString(`1. e4 e5 1-0
+
+1. e4 Ke7 2. d4 *
+
+[Event "bad]
+
+1. Nf3 $x 0-1
+
+1. d4 (1. c4 *
+
+1. c4 c5 1/2-1/2
+`)
+	if got, want := len(games), 2; got != want {
+		t.Fatalf("got %d games, want %d", got, want)
+	}
+	if mainline(games[0]) != "e2e4 e7e5" || mainline(games[1]) != "c2c4 c7c5" {
+		t.Errorf("read %q and %q", mainline(games[0]), mainline(games[1]))
+	}
+	if !errors.Is(err, board.ErrInvalidSAN) {
+		t.Errorf("error %v does not report the illegal move", err)
+	}
+	if !errors.Is(err, ErrSyntax) {
+		t.Errorf("error %v does not report the syntax errors", err)
+	}
+}
@@ -0,0 +1,240 @@
+package pgn
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenTag
+	tokenComment
+	tokenNAG
+	tokenOpenVariation
+	tokenCloseVariation
+	tokenResult
+	tokenSymbol
+)
+
+type token struct {
+	kind  tokenKind
+	text  string // symbol, comment text, result or tag name
+	value string // tag value
+	nag   int
+	line  int
+}
+
+// scanner splits PGN input into tokens. It skips "%" escape lines and
+// a leading byte order mark.
+type scanner struct {
+	r         *bufio.Reader
+	line      int
+	lineStart bool
+	peeked    *token
+	// err is the first error reading the input other than io.EOF. Once
+	// it is set, the scanner returns it for good.
+	err error
+}
+
+func newScanner(r io.Reader) *scanner {
+	br := bufio.NewReader(r)
+	if bom, err := br.Peek(3); err == nil && string(bom) == "\xef\xbb\xbf" {
+		br.Discard(3)
+	}
+	return &scanner{r: br, line: 1, lineStart: true}
+}
+
+// syntaxError reports a problem at the scanner's current line.
+func (s *scanner) syntaxError(format string, args ...any) error {
+	return fmt.Errorf("%w: line %d: %s", ErrSyntax, s.line, fmt.Sprintf(format, args...))
+}
+
+func (s *scanner) readRune() (rune, error) {
+	r, _, err := s.r.ReadRune()
+	if err != nil {
+		return 0, err
+	}
+	s.lineStart = r == '\n'
+	if r == '\n' {
+		s.line++
+	}
+	return r, nil
+}
+
+func (s *scanner) unreadRune(r rune) {
+	s.r.UnreadRune()
+	if r == '\n' {
+		s.line--
+	}
+}
+
+// skipLine discards input up to and including the next newline.
+func (s *scanner) skipLine() (string, error) {
+	text, err := s.r.ReadString('\n')
+	if strings.HasSuffix(text, "\n") {
+		s.line++
+		s.lineStart = true
+	}
+	if err == io.EOF && text != "" {
+		err = nil
+	}
+	return strings.TrimRight(text, "\r\n"), err
+}
+
+func (s *scanner) peek() (token, error) {
+	if s.peeked == nil {
+		t, err := s.scan()
+		if err != nil {
+			return t, err
+		}
+		s.peeked = &t
+	}
+	return *s.peeked, nil
+}
+
+func (s *scanner) next() (token, error) {
+	if s.peeked != nil {
+		t := *s.peeked
+		s.peeked = nil
+		return t, nil
+	}
+	return s.scan()
+}
+
+func (s *scanner) scan() (token, error) {
+	if s.err != nil {
+		return token{}, s.err
+	}
+	for {
+		atLineStart := s.lineStart
+		r, err := s.readRune()
+		if err == io.EOF {
+			return token{kind: tokenEOF, line: s.line}, nil
+		}
+		if err != nil {
+			s.err = err
+			return token{}, err
+		}
+		line := s.line
+		switch {
+		case r == '%' && atLineStart:
+			if _, err := s.skipLine(); err != nil && err != io.EOF {
+				s.err = err
+				return token{}, err
+			}
+		case r == ' ' || r == '\t' || r == '\r' || r == '\n':
+		case r == '[':
+			return s.scanTag()
+		case r == '{':
+			text, err := s.r.ReadString('}')
+			if err != nil {
+				return token{}, s.syntaxError("unterminated comment")
+			}
+			s.line += strings.Count(text, "\n")
+			return token{kind: tokenComment, text: strings.TrimSpace(strings.TrimSuffix(text, "}")), line: line}, nil
+		case r == ';':
+			text, err := s.skipLine()
+			if err != nil && err != io.EOF {
+				s.err = err
+				return token{}, err
+			}
+			return token{kind: tokenComment, text: strings.TrimSpace(text), line: line}, nil
+		case r == '(':
+			return token{kind: tokenOpenVariation, line: line}, nil
+		case r == ')':
+			return token{kind: tokenCloseVariation, line: line}, nil
+		case r == '$':
+			word := s.scanWord()
+			n, err := strconv.Atoi(word)
+			if err != nil {
+				return token{}, s.syntaxError("invalid NAG $%s", word)
+			}
+			return token{kind: tokenNAG, nag: n, line: line}, nil
+		default:
+			s.unreadRune(r)
+			word := s.scanWord()
+			if word == "" {
+				// skip the character, so that reading on gets past it
+				s.readRune()
+				return token{}, s.syntaxError("unexpected character %q", r)
+			}
+			switch word {
+			case ResultWhiteWins, ResultBlackWins, ResultDraw, ResultUnknown:
+				return token{kind: tokenResult, text: word, line: line}, nil
+			}
+			return token{kind: tokenSymbol, text: word, line: line}, nil
+		}
+	}
+}
+
+// scanWord reads up to the next whitespace or PGN delimiter.
+func (s *scanner) scanWord() string {
+	var sb strings.Builder
+	for {
+		r, err := s.readRune()
+		if err != nil {
+			return sb.String()
+		}
+		if strings.ContainsRune(" \t\r\n[]{}();$\"", r) {
+			s.unreadRune(r)
+			return sb.String()
+		}
+		sb.WriteRune(r)
+	}
+}
+
+func (s *scanner) scanTag() (token, error) {
+	line := s.line
+	s.skipSpace()
+	name := s.scanWord()
+	if name == "" {
+		return token{}, s.syntaxError("tag has no name")
+	}
+	s.skipSpace()
+	if r, err := s.readRune(); err != nil || r != '"' {
+		return token{}, s.syntaxError("tag %s has no quoted value", name)
+	}
+	var value strings.Builder
+	for {
+		r, err := s.readRune()
+		if err != nil || r == '\n' {
+			return token{}, s.syntaxError("tag %s has an unterminated value", name)
+		}
+		if r == '"' {
+			break
+		}
+		if r == '\\' {
+			if r, err = s.readRune(); err != nil {
+				return token{}, s.syntaxError("tag %s has an unterminated value", name)
+			}
+		}
+		value.WriteRune(r)
+	}
+	s.skipSpace()
+	if r, err := s.readRune(); err != nil || r != ']' {
+		return token{}, s.syntaxError("tag %s is not closed with ]", name)
+	}
+	return token{kind: tokenTag, text: name, value: value.String(), line: line}, nil
+}
+
+func (s *scanner) skipSpace() {
+	for {
+		r, err := s.readRune()
+		if err != nil {
+			return
+		}
+		if r != ' ' && r != '\t' {
+			s.unreadRune(r)
+			return
+		}
+	}
+}
+
+// ErrSyntax is wrapped by errors for input that is not valid PGN.
+var ErrSyntax = errors.New("pgn syntax error")
@@ -0,0 +1,150 @@
+package pgn
+
+import (
+	"github.com/tygermarshall/blunderbuss/shared/board"
+)
+
+// Node is a position in a game tree. Every node but the root is reached by
+// playing Move from its parent. The first child continues the line the
+// node is on; any others are variations, alternatives to that first move.
+type Node struct {
+	// Move is the move leading to this node, as applied by the board. It is
+	// the zero Move at the root.
+	Move board.Move
+	// Board is the position after Move.
+	Board    board.Board
+	Parent   *Node
+	Children []*Node
+
+	// StartingComment is a comment written before Move. PGN only has room
+	// for one at the start of a variation.
+	StartingComment string
+	// Comment follows Move, or at the root comes before the first move,
+	// with any embedded commands taken out into Commands.
+	Comment string
+	// NAGs are the Numeric Annotation Glyphs on Move, e.g. 1 for "!".
+	NAGs     []int
+	Commands []Command
+}
+
+func newRoot(b board.Board) *Node {
+	return &Node{Board: b}
+}
+
+// Play returns the child reached by playing m from n. If no child plays m
+// yet, one is added after the existing children, so it becomes the
+// mainline continuation if n had none and a variation otherwise.
+func (n *Node) Play(m board.Move) (*Node, error) {
+	for _, c := range n.Children {
+		if c.Move.From == m.From && c.Move.To == m.To && c.Move.Promotion == m.Promotion {
+			return c, nil
+		}
+	}
+	next, applied, err := n.Board.MovePiece(m)
+	if err != nil {
+		return nil, err
+	}
+	c := &Node{Move: applied, Board: next, Parent: n}
+	n.Children = append(n.Children, c)
+	return c, nil
+}
+
+// Next returns the mainline continuation from n, or nil at the end of a
+// line.
+func (n *Node) Next() *Node {
+	if len(n.Children) == 0 {
+		return nil
+	}
+	return n.Children[0]
+}
+
+// Variations returns the alternatives to the mainline continuation.
+func (n *Node) Variations() []*Node {
+	if len(n.Children) < 2 {
+		return nil
+	}
+	return n.Children[1:]
+}
+
+// IsMainline reports whether n is on the game's mainline, that is whether
+// every node from the root to n is its parent's first child.
+func (n *Node) IsMainline() bool {
+	for ; n.Parent != nil; n = n.Parent {
+		if n.Parent.Children[0] != n {
+			return false
+		}
+	}
+	return true
+}
+
+// Moves returns the moves played from the root to reach n.
+func (n *Node) Moves() []board.Move {
+	var moves []board.Move
+	for ; n.Parent != nil; n = n.Parent {
+		moves = append(moves, n.Move)
+	}
+	for i, j := 0, len(moves)-1; i < j; i, j = i+1, j-1 {
+		moves[i], moves[j] = moves[j], moves[i]
+	}
+	return moves
+}
+
+// Promote moves n one place towards the front of its parent's children.
+// Promoting the first variation makes it the continuation, demoting the
+// old one to a variation.
+func (n *Node) Promote() {
+	i := n.index()
+	if i <= 0 {
+		return
+	}
+	siblings := n.Parent.Children
+	siblings[i-1], siblings[i] = siblings[i], siblings[i-1]
+}
+
+// PromoteToMain makes n its parent's continuation, keeping the order of
+// the other children.
+func (n *Node) PromoteToMain() {
+	i := n.index()
+	if i <= 0 {
+		return
+	}
+	siblings := n.Parent.Children
+	copy(siblings[1:i+1], siblings[:i])
+	siblings[0] = n
+}
+
+// Demote moves n one place towards the back of its parent's children.
+func (n *Node) Demote() {
+	i := n.index()
+	if i < 0 || i == len(n.Parent.Children)-1 {
+		return
+	}
+	siblings := n.Parent.Children
+	siblings[i], siblings[i+1] = siblings[i+1], siblings[i]
+}
+
+// Delete removes n and every move after it from the tree. Deleting the
+// root does nothing.
+func (n *Node) Delete() {
+	i := n.index()
+	if i < 0 {
+		return
+	}
+	siblings := n.Parent.Children
+	n.Parent.Children = append(siblings[:i:i], siblings[i+1:]...)
+	n.Parent = nil
+}
+
+// index returns n's position among its parent's children, or -1 at the
+// root.
+func (n *Node) index() int {
+	if n.Parent == nil {
+		return -1
+	}
+	for i, c := range n.Parent.Children {
+		if c == n {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,115 @@
+package pgn
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tygermarshall/blunderbuss/shared/board"
+)
+
+// playUCI plays moves from n, failing t on the first illegal one.
+func playUCI(t *testing.T, n *Node, moves ...string) *Node {
+	t.Helper()
+	for _, uci := range moves {
+		m, err := board.ParseUCI(uci)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n, err = n.Play(m); err != nil {
+			t.Fatalf("%s: %v", uci, err)
+		}
+	}
+	return n
+}
+
+// children returns the first moves of n's children in UCI.
+func children(n *Node) string {
+	var moves []string
+	for _, c := range n.Children {
+		moves = append(moves, c.Move.UCI())
+	}
+	return strings.Join(moves, " ")
+}
+
+func TestPlay(t *testing.T) {
+	g := NewGame()
+	e4 := playUCI(t, g.Root, "e2e4")
+	if again := playUCI(t, g.Root, "e2e4"); again != e4 {
+		t.Error("playing the same move twice added a second child")
+	}
+	d4 := playUCI(t, g.Root, "d2d4")
+	if children(g.Root) != "e2e4 d2d4" || g.Root.Next() != e4 {
+		t.Errorf("children %q, want e4 first", children(g.Root))
+	}
+	if !e4.IsMainline() || d4.IsMainline() {
+		t.Error("IsMainline is wrong")
+	}
+
+	end := playUCI(t, d4, "d7d5", "c2c4")
+	var moves []string
+	for _, m := range end.Moves() {
+		moves = append(moves, m.UCI())
+	}
+	if got := strings.Join(moves, " "); got != "d2d4 d7d5 c2c4" {
+		t.Errorf("Moves() = %q", got)
+	}
+	if got, want := end.Board.FEN(), "rnbqkbnr/ppp1pppp/8/3p4/2PP4/8/PP2PPPP/RNBQKBNR b KQkq c3 0 2"; got != want {
+		t.Errorf("board %s, want %s", got, want)
+	}
+
+	m, _ := board.ParseUCI("e4e5")
+	if _, err := e4.Play(m); err == nil {
+		t.Error("Play accepted a move for the wrong side")
+	}
+	if len(e4.Children) != 0 {
+		t.Error("an illegal move was added to the tree")
+	}
+}
+
+func TestReorderVariations(t *testing.T) {
+	g := NewGame()
+	for _, uci := range []string{"e2e4", "d2d4", "c2c4", "g1f3"} {
+		playUCI(t, g.Root, uci)
+	}
+	c4 := g.Root.Children[2]
+
+	steps := []struct {
+		name string
+		do   func()
+		want string
+	}{
+		{"Promote", c4.Promote, "e2e4 c2c4 d2d4 g1f3"},
+		{"Promote", c4.Promote, "c2c4 e2e4 d2d4 g1f3"},
+		{"Promote the continuation", c4.Promote, "c2c4 e2e4 d2d4 g1f3"},
+		{"Demote", c4.Demote, "e2e4 c2c4 d2d4 g1f3"},
+		{"Demote", g.Root.Children[3].Demote, "e2e4 c2c4 d2d4 g1f3"},
+		{"PromoteToMain", g.Root.Children[3].PromoteToMain, "g1f3 e2e4 c2c4 d2d4"},
+		{"Delete", c4.Delete, "g1f3 e2e4 d2d4"},
+		{"Delete the root", g.Root.Delete, "g1f3 e2e4 d2d4"},
+	}
+	for _, s := range steps {
+		s.do()
+		if got := children(g.Root); got != s.want {
+			t.Fatalf("after %s: %q, want %q", s.name, got, s.want)
+		}
+	}
+	if c4.Parent != nil {
+		t.Error("a deleted node still has a parent")
+	}
+}
+
+// Deleting a move takes every move after it with it.
+func TestDeleteLine(t *testing.T) {
+	g := NewGame()
+	playUCI(t, g.Root, "e2e4", "e7e5", "g1f3", "b8c6")
+	e5 := g.Root.Next().Next()
+	playUCI(t, e5, "f1c4")
+	e5.Next().Delete()
+	if got, want := mainline(g), "e2e4 e7e5 f1c4"; got != want {
+		t.Errorf("mainline %q, want %q", got, want)
+	}
+	g.Root.Next().Delete()
+	if len(g.Root.Children) != 0 || len(g.Mainline()) != 0 {
+		t.Errorf("mainline %q after deleting the first move", mainline(g))
+	}
+}
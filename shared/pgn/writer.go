@@ -0,0 +1,189 @@
+package pgn
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/tygermarshall/blunderbuss/shared/pieces"
+)
+
+// maxLineLength is the width movetext is wrapped at. The standard allows
+// up to 255 characters but 80 is what other tools write.
+const maxLineLength = 80
+
+// Write writes g in PGN export format: the Seven Tag Roster in order,
+// other tags after it, then the movetext in SAN and the result.
+func Write(w io.Writer, g *Game) error {
+	_, err := io.WriteString(w, g.String())
+	return err
+}
+
+// WriteAll writes games one after another, separated by blank lines.
+func WriteAll(w io.Writer, games []*Game) error {
+	for _, g := range games {
+		if err := Write(w, g); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// String returns g in PGN export format, with its comments, NAGs and
+// variations.
+func (g *Game) String() string {
+	var sb strings.Builder
+	for _, t := range g.exportTags() {
+		fmt.Fprintf(&sb, "[%s \"%s\"]\n", t.Name, escapeTag(t.Value))
+	}
+	sb.WriteByte('\n')
+
+	lw := lineWriter{sb: &sb}
+	lw.writeComment(g.Root.commentText())
+	// a game starting with black to move opens with "1..." (§8.2.2.2)
+	writeLine(&lw, g.Root, true)
+	lw.write(g.result())
+	sb.WriteString("\n\n")
+	return sb.String()
+}
+
+// writeLine writes the moves played from n along its first children,
+// each followed by its alternatives in parentheses. forceNumber asks for a
+// move number before the first move even if black plays it.
+func writeLine(lw *lineWriter, n *Node, forceNumber bool) {
+	for len(n.Children) > 0 {
+		next := n.Children[0]
+		writeMove(lw, next, forceNumber)
+		for _, v := range n.Variations() {
+			lw.open()
+			writeMove(lw, v, true)
+			writeLine(lw, v, v.commentText() != "")
+			lw.close()
+		}
+		forceNumber = len(n.Children) > 1 || next.commentText() != ""
+		n = next
+	}
+}
+
+// writeMove writes n's move with its move number, NAGs and comments.
+func writeMove(lw *lineWriter, n *Node, forceNumber bool) {
+	if n.StartingComment != "" {
+		lw.writeComment(n.StartingComment)
+		forceNumber = true
+	}
+	b := n.Parent.Board
+	if b.SideToMove == pieces.White {
+		lw.write(fmt.Sprintf("%d.", b.FullmoveNumber))
+	} else if forceNumber {
+		lw.write(fmt.Sprintf("%d...", b.FullmoveNumber))
+	}
+	san, err := b.SAN(n.Move)
+	if err != nil {
+		san = "--"
+	}
+	lw.write(san)
+	for _, nag := range n.NAGs {
+		lw.write(fmt.Sprintf("$%d", nag))
+	}
+	lw.writeComment(n.commentText())
+}
+
+func (g *Game) result() string {
+	switch g.Result {
+	case ResultWhiteWins, ResultBlackWins, ResultDraw:
+		return g.Result
+	}
+	return ResultUnknown
+}
+
+// exportTags returns the Seven Tag Roster, filled with the standard
+// unknown values where missing, followed by the game's other tags.
+func (g *Game) exportTags() []Tag {
+	tags := make([]Tag, 0, len(g.Tags)+len(SevenTagRoster))
+	for _, name := range SevenTagRoster {
+		value, ok := g.Tag(name)
+		switch {
+		case name == "Result":
+			value = g.result()
+		case !ok && name == "Date":
+			value = "????.??.??"
+		case !ok:
+			value = "?"
+		}
+		tags = append(tags, Tag{Name: name, Value: value})
+	}
+	for _, t := range g.Tags {
+		if !isRosterTag(t.Name) {
+			tags = append(tags, t)
+		}
+	}
+	return tags
+}
+
+func isRosterTag(name string) bool {
+	for _, n := range SevenTagRoster {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+func escapeTag(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s)
+}
+
+// lineWriter joins tokens with spaces and wraps before a token would
+// run past maxLineLength.
+type lineWriter struct {
+	sb   *strings.Builder
+	line int
+	// opening is set when the next token starts a variation.
+	opening bool
+}
+
+// writeComment writes text in braces, keeping its line breaks. A line of
+// a comment is never wrapped, so that reading it back gives the same
+// text. PGN has no escape for "}" in a comment, so any are dropped.
+func (lw *lineWriter) writeComment(text string) {
+	text = strings.ReplaceAll(text, "}", "")
+	if strings.TrimSpace(text) == "" {
+		return
+	}
+	lines := strings.Split("{"+text+"}", "\n")
+	lw.write(lines[0])
+	for _, line := range lines[1:] {
+		lw.sb.WriteByte('\n')
+		lw.line = 0
+		lw.write(line)
+	}
+}
+
+// open starts a variation: the next token is written straight after "(".
+func (lw *lineWriter) open() {
+	lw.opening = true
+}
+
+// close ends a variation, writing ")" straight after the last token.
+func (lw *lineWriter) close() {
+	lw.sb.WriteByte(')')
+	lw.line++
+}
+
+func (lw *lineWriter) write(token string) {
+	if lw.opening {
+		token = "(" + token
+		lw.opening = false
+	}
+	switch {
+	case lw.line == 0:
+	case lw.line+1+len(token) > maxLineLength:
+		lw.sb.WriteByte('\n')
+		lw.line = 0
+	default:
+		lw.sb.WriteByte(' ')
+		lw.line++
+	}
+	lw.sb.WriteString(token)
+	lw.line += len(token)
+}
@@ -0,0 +1,144 @@
+package pgn
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tygermarshall/blunderbuss/shared/board"
+)
+
+func TestWriteNumbersBlackFirstMove(t *testing.T) {
+	b, err := board.ParseFEN("rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := NewGame()
+	g.SetStartBoard(b)
+	for _, san := range []string{"e5", "Nf3"} {
+		m, err := g.Board().ParseSAN(san)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := g.AppendMoves(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got := g.String()
+	if want := "\n\n1... e5 2. Nf3 *\n"; !strings.Contains(got, want) {
+		t.Errorf("got\n%s\nwant movetext %q", got, strings.TrimSpace(want))
+	}
+}
+
+// annotated returns a game with every kind of annotation the writer
+// handles: comments before the game, before a variation and after moves,
+// commands, NAGs and nested variations.
+func annotated(t *testing.T) *Game {
+	t.Helper()
+	g := NewGame()
+	g.SetTag("Event", "Annotated")
+	g.Root.Comment = "A short game."
+	e4 := playUCI(t, g.Root, "e2e4")
+	e4.NAGs = []int{1}
+	e4.SetClock(time.Hour + 59*time.Minute + 58*time.Second)
+	e4.SetEval(Eval{Centipawns: 35})
+	e5 := playUCI(t, e4, "e7e5")
+	e5.Comment = "The most common reply.\nBlack takes the centre too."
+	c5 := playUCI(t, e4, "c7c5")
+	c5.StartingComment = "Sicilian"
+	c5.NAGs = []int{5, 14}
+	d4 := playUCI(t, c5, "g1f3", "d7d6", "d2d4")
+	playUCI(t, c5, "c2c3")
+	playUCI(t, d4.Parent, "f1b5").SetEval(Eval{Mate: -3})
+	qh5 := playUCI(t, e5, "d1h5")
+	qh5.Comment = "Too early {really}."
+	playUCI(t, qh5, "b8c6")
+	g.Result = ResultWhiteWins
+	return g
+}
+
+func TestWriteAnnotations(t *testing.T) {
+	got := annotated(t).String()
+	want := `[Event "Annotated"]
+[Site "?"]
+[Date "????.??.??"]
+[Round "?"]
+[White "?"]
+[Black "?"]
+[Result "1-0"]
+
+{A short game.} 1. e4 $1 {[%clk 1:59:58] [%eval 0.35]} 1... e5
+{The most common reply.
+Black takes the centre too.} ({Sicilian} 1... c5 $5 $14 2. Nf3 (2. c3) 2... d6
+3. d4 (3. Bb5+ {[%eval #-3]})) 2. Qh5 {Too early {really.} 2... Nc6 1-0
+
+`
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestWriteReadRoundTrip(t *testing.T) {
+	g := annotated(t)
+	text := g.String()
+	read := parseOne(t, text)
+	if again := read.String(); again != text {
+		t.Fatalf("read back and written as\n%s\nwant\n%s", again, text)
+	}
+
+	e4 := read.Root.Next()
+	if d, ok := e4.Clock(); !ok || d != time.Hour+59*time.Minute+58*time.Second {
+		t.Errorf("clock %v, %v", d, ok)
+	}
+	if e, ok := e4.Eval(); !ok || e != (Eval{Centipawns: 35}) {
+		t.Errorf("eval %v, %v", e, ok)
+	}
+	if e4.Comment != "" {
+		t.Errorf("commands left in the comment %q", e4.Comment)
+	}
+	if got := e4.Next().Comment; got != "The most common reply.\nBlack takes the centre too." {
+		t.Errorf("comment %q lost its line break", got)
+	}
+	bb5 := e4.Variations()[0].Next().Next().Variations()[0]
+	if e, ok := bb5.Eval(); !ok || e != (Eval{Mate: -3}) {
+		t.Errorf("eval %v, %v", e, ok)
+	}
+}
+
+// A comment line longer than a PGN line is written whole rather than
+// wrapped, so that it reads back unchanged.
+func TestWriteLongComment(t *testing.T) {
+	g := NewGame()
+	e4 := playUCI(t, g.Root, "e2e4")
+	e4.Comment = strings.Repeat("word ", 30) + "end"
+	read := parseOne(t, g.String())
+	if got := read.Root.Next().Comment; got != e4.Comment {
+		t.Errorf("comment read back as %q", got)
+	}
+}
+
+func TestWriteAfterReordering(t *testing.T) {
+	g := annotated(t)
+	e4 := g.Root.Next()
+	// 1... c5 becomes the continuation, and 2. c3 takes over from the
+	// deleted 2. Nf3 line
+	e4.Variations()[0].Promote()
+	e4.Next().Next().Delete()
+	got := g.String()
+	want := "{A short game.} 1. e4 $1 {[%clk 1:59:58] [%eval 0.35]} {Sicilian} 1... c5 $5 $14\n" +
+		"(1... e5 {The most common reply.\n" +
+		"Black takes the centre too.} 2. Qh5 {Too early {really.} 2... Nc6) 2. c3 1-0\n"
+	if !strings.Contains(got, want) {
+		t.Errorf("got\n%s\nwant movetext\n%s", got, want)
+	}
+
+	// back to 1... e5, without the Sicilian
+	e4.Next().Demote()
+	e4.Variations()[0].Delete()
+	want = "{A short game.} 1. e4 $1 {[%clk 1:59:58] [%eval 0.35]} 1... e5\n" +
+		"{The most common reply.\n" +
+		"Black takes the centre too.} 2. Qh5 {Too early {really.} 2... Nc6 1-0\n"
+	if got := g.String(); !strings.Contains(got, want) {
+		t.Errorf("got\n%s\nwant movetext\n%s", got, want)
+	}
+}
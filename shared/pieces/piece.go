@@ -1,5 +1,10 @@
 package pieces
 
+import (
+	"fmt"
+	"strings"
+)
+
 type PieceType int
 
 type Team int
@@ -17,6 +22,18 @@ func (team Team) String() string {
 	}
 }
 
+// Opponent returns the team playing against team. Neutral has no opponent
+// and is returned unchanged.
+func (team Team) Opponent() Team {
+	switch team {
+	case White:
+		return Black
+	case Black:
+		return White
+	}
+	return team
+}
+
 const (
 	Pawn PieceType = iota
 	Knight
@@ -46,6 +63,26 @@ func (piece PieceType) String() string {
 	}
 }
 
+// ParsePieceType returns the piece type named by s, accepting either the
+// full name ("queen") or the algebraic letter ("q" or "Q").
+func ParsePieceType(s string) (PieceType, error) {
+	switch strings.ToLower(s) {
+	case "p", "pawn":
+		return Pawn, nil
+	case "n", "knight":
+		return Knight, nil
+	case "b", "bishop":
+		return Bishop, nil
+	case "r", "rook":
+		return Rook, nil
+	case "q", "queen":
+		return Queen, nil
+	case "k", "king":
+		return King, nil
+	}
+	return Empty, fmt.Errorf("unknown piece type %q", s)
+}
+
 const (
 	White Team = iota
 	Black
@@ -53,7 +90,6 @@ const (
 )
 
 type Piece struct {
-	Type      PieceType `json:"type"`
-	Team      Team      `json:"team"`
-	MoveCount int       `json:"move_count"`
+	Type PieceType `json:"type"`
+	Team Team      `json:"team"`
 }
@@ -8,3 +8,20 @@ type CreateGameReponse struct {
 	GameId string      `json:"gameId"`
 	Board  board.Board `json:"board"`
 }
+
+// MoveResponse is returned after a move is played. Move is the move as
+// applied, including the captured piece and flags, and UCI the same move
+// in UCI long algebraic form, e.g. "e7e8q".
+type MoveResponse struct {
+	GameId string      `json:"gameId"`
+	Board  board.Board `json:"board"`
+	Move   board.Move  `json:"move"`
+	UCI    string      `json:"uci"`
+}
+
+// ErrorResponse is the body of every failed request. Code is a stable
+// machine-readable identifier such as "wrong_turn" or "game_not_found".
+type ErrorResponse struct {
+	Code    string `json:"code"`
+	Message string `json:"error"`
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -97,16 +98,26 @@ func readNextCmd(conn *websocket.Conn) tea.Cmd {
 
 func movePieceCmd(m model) tea.Cmd {
 	return func() tea.Msg {
-		resp, err := http.Post(serverBaseURL+"/games/"+m.gameId+"/move", "application/json", nil)
+		body, err := json.Marshal(map[string]string{
+			"move": board.NewMove(board.A2, board.A4).UCI(),
+		})
+		if err != nil {
+			return gameCreateErrMsg{Err: err}
+		}
+		resp, err := http.Post(serverBaseURL+"/games/"+m.gameId+"/move", "application/json", bytes.NewReader(body))
 		if err != nil {
 			return gameCreateErrMsg{Err: err}
 		}
 		defer resp.Body.Close()
 		debugLog(resp)
 		if resp.StatusCode != http.StatusCreated {
-			return gameCreateErrMsg{Err: fmt.Errorf("create game: status %d", resp.StatusCode)}
+			var apiErr shared.ErrorResponse
+			if err := json.NewDecoder(resp.Body).Decode(&apiErr); err == nil && apiErr.Message != "" {
+				return gameCreateErrMsg{Err: fmt.Errorf("move: %s", apiErr.Message)}
+			}
+			return gameCreateErrMsg{Err: fmt.Errorf("move: status %d", resp.StatusCode)}
 		}
-		var out shared.CreateGameReponse
+		var out shared.MoveResponse
 
 		if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
 			return gameCreateErrMsg{Err: err}
@@ -201,7 +212,7 @@ func (m model) View() string {
 		output.WriteString("no game started yet\n")
 	}
 
-	moveCountString := fmt.Sprintf("Move count: %d", m.Board.MoveCount)
+	moveCountString := fmt.Sprintf("Move count: %d (%s to move)", m.Board.FullmoveNumber, m.Board.SideToMove)
 	output.WriteString(moveCountString)
 
 	output.WriteString("\n")